@@ -0,0 +1,169 @@
+package docker
+
+import (
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/docker/docker/client"
+
+	"github.com/riete/docker/container"
+	"github.com/riete/docker/image"
+	"github.com/riete/docker/network"
+	"github.com/riete/docker/system"
+	"github.com/riete/docker/volume"
+)
+
+type clientOptions struct {
+	host       string
+	caCertPath string
+	certPath   string
+	keyPath    string
+	version    string
+	timeout    time.Duration
+	httpClient *http.Client
+}
+
+type Option func(*clientOptions)
+
+// WithHost docker daemon host, i.e. unix:///var/run/docker.sock or tcp://127.0.0.1:2376
+func WithHost(host string) Option {
+	return func(o *clientOptions) {
+		o.host = host
+	}
+}
+
+// WithTLS ca, cert and key file paths used to connect the daemon over tls
+func WithTLS(caCertPath, certPath, keyPath string) Option {
+	return func(o *clientOptions) {
+		o.caCertPath = caCertPath
+		o.certPath = certPath
+		o.keyPath = keyPath
+	}
+}
+
+// WithAPIVersion pin engine api version, i.e. 1.43, api version negotiation is disabled
+func WithAPIVersion(version string) Option {
+	return func(o *clientOptions) {
+		o.version = version
+	}
+}
+
+// WithTimeout timeout of each http request send to the daemon
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *clientOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithHTTPClient use custom http.Client, host, tls and timeout options are applied on top of a copy of it
+// a nil Transport defaults to a new *http.Transport, DialContext is replaced by the dialer of the daemon host
+// host and tls can not be applied to other transport types
+func WithHTTPClient(c *http.Client) Option {
+	return func(o *clientOptions) {
+		o.httpClient = c
+	}
+}
+
+func (o clientOptions) clientOpts() []client.Opt {
+	var opts []client.Opt
+	// custom http client first, so that environment and host options configure its transport
+	if o.httpClient != nil {
+		opts = append(opts, client.WithHTTPClient(cloneHTTPClient(o.httpClient)))
+	}
+	// environment(DOCKER_HOST, DOCKER_CERT_PATH, etc.) is default, explicit options override it
+	opts = append(opts, client.FromEnv)
+	if o.httpClient != nil && o.host == "" && os.Getenv(client.EnvOverrideHost) == "" {
+		// the default host is only set up on the default transport, set it up on the custom one
+		opts = append(opts, client.WithHost(client.DefaultDockerHost))
+	}
+	if o.host != "" {
+		opts = append(opts, client.WithHost(o.host))
+	}
+	if o.caCertPath != "" || o.certPath != "" || o.keyPath != "" {
+		opts = append(opts, client.WithTLSClientConfig(o.caCertPath, o.certPath, o.keyPath))
+	}
+	if o.timeout > 0 {
+		opts = append(opts, client.WithTimeout(o.timeout))
+	}
+	if o.version != "" {
+		opts = append(opts, client.WithVersion(o.version))
+	} else {
+		opts = append(opts, client.WithAPIVersionNegotiation())
+	}
+	return opts
+}
+
+// cloneHTTPClient copy c and its transport, so that applying options does not modify the caller's client
+func cloneHTTPClient(c *http.Client) *http.Client {
+	hc := *c
+	switch t := hc.Transport.(type) {
+	case nil:
+		// not http.DefaultTransport, its DialContext would take precedence over the socket dialer of the host
+		hc.Transport = &http.Transport{}
+	case *http.Transport:
+		tr := t.Clone()
+		tr.DialContext = nil
+		hc.Transport = tr
+	}
+	return &hc
+}
+
+// Client own one engine connection shared by container, image, network, volume and system clients
+type Client struct {
+	c          *client.Client
+	containers *container.ContainerClient
+	images     *image.ImageClient
+	networks   *network.NetworkClient
+	volumes    *volume.VolumeClient
+	system     *system.SystemClient
+}
+
+func (c Client) Containers() *container.ContainerClient {
+	return c.containers
+}
+
+func (c Client) Images() *image.ImageClient {
+	return c.images
+}
+
+func (c Client) Networks() *network.NetworkClient {
+	return c.networks
+}
+
+func (c Client) Volumes() *volume.VolumeClient {
+	return c.volumes
+}
+
+func (c Client) System() *system.SystemClient {
+	return c.system
+}
+
+// Engine return the underlying engine client for api not wrapped by this package
+func (c Client) Engine() *client.Client {
+	return c.c
+}
+
+// Close release the http transport, all sub clients are unusable after close
+func (c Client) Close() error {
+	return c.c.Close()
+}
+
+func NewClient(options ...Option) (*Client, error) {
+	o := clientOptions{}
+	for _, option := range options {
+		option(&o)
+	}
+	c, err := client.NewClientWithOpts(o.clientOpts()...)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{
+		c:          c,
+		containers: container.NewContainerClientFromClient(c),
+		images:     image.NewImageClientFromClient(c),
+		networks:   network.NewNetworkClientFromClient(c),
+		volumes:    volume.NewVolumeClientFromClient(c),
+		system:     system.NewSystemClientFromClient(c),
+	}, nil
+}
@@ -0,0 +1,118 @@
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/docker/docker/api/types/filters"
+)
+
+// Kind the resource and operation filters are used for, each kind accepts different keys
+type Kind string
+
+const (
+	ContainerList  Kind = "container list"
+	ContainerPrune Kind = "container prune"
+	ImageList      Kind = "image list"
+	ImagePrune     Kind = "image prune"
+	NetworkList    Kind = "network list"
+	NetworkPrune   Kind = "network prune"
+	VolumeList     Kind = "volume list"
+	VolumePrune    Kind = "volume prune"
+)
+
+// validKeys filter keys accepted by the daemon for each kind
+var validKeys = map[Kind][]string{
+	ContainerList: {
+		"ancestor", "before", "expose", "exited", "health", "id", "isolation", "is-task", "label", "name",
+		"network", "publish", "since", "status", "volume",
+	},
+	ContainerPrune: {"until", "label", "label!"},
+	ImageList:      {"before", "dangling", "label", "reference", "since", "until"},
+	ImagePrune:     {"dangling", "until", "label", "label!"},
+	NetworkList:    {"dangling", "driver", "id", "label", "name", "scope", "type"},
+	NetworkPrune:   {"until", "label", "label!"},
+	VolumeList:     {"dangling", "driver", "label", "name"},
+	VolumePrune:    {"all", "label", "label!"},
+}
+
+// Builder build filters.Args, a key can be added multiple times, values of the same key are OR-ed
+// different keys are AND-ed, i.e. New(ContainerList).Add("status", "exited", "created").Label("app", "api")
+type Builder struct {
+	kind Kind
+	args filters.Args
+	errs []error
+}
+
+func New(kind Kind) *Builder {
+	return &Builder{kind: kind, args: filters.NewArgs()}
+}
+
+// Add values of key, an invalid key is reported by Build
+func (b *Builder) Add(key string, values ...string) *Builder {
+	if !slices.Contains(validKeys[b.kind], key) {
+		b.errs = append(b.errs, fmt.Errorf("invalid filter key %q for %s", key, b.kind))
+		return b
+	}
+	for _, v := range values {
+		b.args.Add(key, v)
+	}
+	return b
+}
+
+func label(key, value string) string {
+	if value == "" {
+		return key
+	}
+	return key + "=" + value
+}
+
+// Label match resources having label key, and value if it is not empty
+func (b *Builder) Label(key, value string) *Builder {
+	return b.Add("label", label(key, value))
+}
+
+// LabelNot match resources not having label key, or not having the value if it is not empty, only for prune
+func (b *Builder) LabelNot(key, value string) *Builder {
+	return b.Add("label!", label(key, value))
+}
+
+// Until match resources created before t
+func (b *Builder) Until(t time.Time) *Builder {
+	return b.Add("until", strconv.FormatInt(t.Unix(), 10))
+}
+
+// OlderThan match resources created more than d ago
+func (b *Builder) OlderThan(d time.Duration) *Builder {
+	return b.Add("until", d.String())
+}
+
+// Before match resources created before reference, reference is container name or id for containers
+// and image reference or id for images
+func (b *Builder) Before(reference string) *Builder {
+	return b.Add("before", reference)
+}
+
+// Since match resources created after reference, see Before
+func (b *Builder) Since(reference string) *Builder {
+	return b.Add("since", reference)
+}
+
+// Build return the filters, all invalid keys are reported together
+func (b *Builder) Build() (filters.Args, error) {
+	return b.args, errors.Join(b.errs...)
+}
+
+// Validate check that all keys of args are accepted by kind
+func Validate(kind Kind, args filters.Args) error {
+	var errs []error
+	for _, key := range args.Keys() {
+		if !slices.Contains(validKeys[kind], key) {
+			errs = append(errs, fmt.Errorf("invalid filter key %q for %s", key, kind))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,113 @@
+package filter
+
+import (
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/filters"
+)
+
+func TestBuilder(t *testing.T) {
+	until := time.Unix(1700000000, 0)
+	tests := []struct {
+		name    string
+		builder *Builder
+		want    map[string][]string
+	}{
+		{
+			name:    "values of a key are kept",
+			builder: New(ContainerList).Add("status", "exited", "created"),
+			want:    map[string][]string{"status": {"created", "exited"}},
+		},
+		{
+			name:    "repeated labels",
+			builder: New(ContainerList).Label("app", "api").Label("env", ""),
+			want:    map[string][]string{"label": {"app=api", "env"}},
+		},
+		{
+			name:    "negated label for prune",
+			builder: New(VolumePrune).LabelNot("keep", "true"),
+			want:    map[string][]string{"label!": {"keep=true"}},
+		},
+		{
+			name:    "until time and duration",
+			builder: New(ImagePrune).Until(until).OlderThan(24 * time.Hour),
+			want:    map[string][]string{"until": {"1700000000", "24h0m0s"}},
+		},
+		{
+			name:    "before and since",
+			builder: New(ImageList).Before("nginx:1.25").Since("nginx:1.24"),
+			want:    map[string][]string{"before": {"nginx:1.25"}, "since": {"nginx:1.24"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := tt.builder.Build()
+			if err != nil {
+				t.Fatalf("Build error: %v", err)
+			}
+			got := make(map[string][]string)
+			for _, key := range args.Keys() {
+				got[key] = args.Get(key)
+				// values of a key have no order
+				slices.Sort(got[key])
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("filters = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuilderInvalidKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		builder *Builder
+		invalid []string
+	}{
+		{"negated label for list", New(ContainerList).LabelNot("app", ""), []string{`"label!"`}},
+		{"until for volume prune", New(VolumePrune).OlderThan(time.Hour), []string{`"until"`}},
+		{"all invalid keys are reported", New(NetworkList).Add("status", "x").Since("y"), []string{`"status"`, `"since"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.builder.Build()
+			if err == nil {
+				t.Fatal("Build error = nil, want invalid key error")
+			}
+			for _, key := range tt.invalid {
+				if !strings.Contains(err.Error(), "invalid filter key "+key) {
+					t.Errorf("error = %v, want invalid filter key %s", err, key)
+				}
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		kind  Kind
+		args  filters.Args
+		valid bool
+	}{
+		{ContainerList, filters.Args{}, true},
+		{ContainerList, filters.NewArgs(filters.Arg("status", "running"), filters.Arg("label", "a")), true},
+		{ContainerList, filters.NewArgs(filters.Arg("until", "1h")), false},
+		{ImagePrune, filters.NewArgs(filters.Arg("dangling", "false"), filters.Arg("label!", "keep")), true},
+		{ImageList, filters.NewArgs(filters.Arg("label!", "keep")), false},
+		{VolumePrune, filters.NewArgs(filters.Arg("all", "true")), true},
+		{NetworkPrune, filters.NewArgs(filters.Arg("dangling", "true")), false},
+	}
+	for n, tt := range tests {
+		t.Run(string(tt.kind)+" "+strconv.Itoa(n), func(t *testing.T) {
+			err := Validate(tt.kind, tt.args)
+			if (err == nil) != tt.valid {
+				t.Errorf("Validate(%s, %v) = %v, want valid %t", tt.kind, tt.args.Keys(), err, tt.valid)
+			}
+		})
+	}
+}
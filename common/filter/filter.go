@@ -4,6 +4,7 @@ import (
 	"github.com/docker/docker/api/types/filters"
 )
 
+// NewFilterArgs one value per key, use New for repeated keys and validation
 func NewFilterArgs(f map[string]string) filters.Args {
 	var args []filters.KeyValuePair
 	for k, v := range f {
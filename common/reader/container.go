@@ -3,11 +3,33 @@ package reader
 import (
 	"bufio"
 	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
 	"io"
+	"strings"
+	"time"
 
 	"github.com/docker/docker/pkg/stdcopy"
 )
 
+type Stream string
+
+const (
+	Stdout Stream = "stdout"
+	Stderr Stream = "stderr"
+
+	frameHeaderSize = 8
+)
+
+type LogLine struct {
+	Stream Stream
+	// Timestamp is zero unless logs are requested with timestamps
+	Timestamp time.Time
+	Line      string
+}
+
 func ParseToStdoutStderr(r io.Reader) (string, string, error) {
 	var stdout bytes.Buffer
 	var stderr bytes.Buffer
@@ -21,33 +43,158 @@ func ParseToCombinedOutput(r io.Reader) (string, error) {
 	return b.String(), err
 }
 
+// ParseToCombinedStreamOutput yield log lines(with trailing "\n") of multiplexed stream, error is sent as a line
+//
+// Deprecated: use DecodeLogStream, which reports stream, timestamp and error separately
 func ParseToCombinedStreamOutput(r io.Reader) <-chan string {
 	ch := make(chan string)
-	rb := bufio.NewReader(r)
-	b := &bytes.Buffer{}
+	lines, errCh := DecodeLogStream(context.Background(), r, false, false)
+	go func() {
+		defer close(ch)
+		for l := range lines {
+			ch <- l.Line + "\n"
+		}
+		if err := <-errCh; err != nil {
+			ch <- err.Error()
+		}
+	}()
+	return ch
+}
 
+// DecodeLogStream decode container logs or attach output into lines
+// set tty to true if container is created with tty, its output is not multiplexed and all lines are stdout
+// set timestamps to true if logs are requested with timestamps, the leading timestamp is parsed into LogLine.Timestamp
+// lines channel is closed when r is drained, error channel receives at most one error then closed
+// cancel ctx to stop decoding if lines are no longer consumed, close r too if a read may block, i.e. following logs
+func DecodeLogStream(ctx context.Context, r io.Reader, tty, timestamps bool) (<-chan LogLine, <-chan error) {
+	ch := make(chan LogLine)
+	errCh := make(chan error, 1)
+	d := &logDecoder{ctx: ctx, ch: ch, timestamps: timestamps, partial: make(map[Stream]*bytes.Buffer)}
 	go func() {
+		defer close(errCh)
 		defer close(ch)
-		for {
-			d, err := rb.ReadBytes('\n')
-			if _, err := b.Write(d); err != nil {
-				ch <- err.Error()
-				return
+		var err error
+		if tty {
+			err = d.decodeRaw(r)
+		} else {
+			err = d.decodeFrames(r)
+		}
+		if err != nil {
+			errCh <- err
+		}
+	}()
+	return ch, errCh
+}
+
+type logDecoder struct {
+	ctx        context.Context
+	ch         chan<- LogLine
+	timestamps bool
+	// partial keep the incomplete last line of each stream, a line may span frames
+	partial map[Stream]*bytes.Buffer
+}
+
+func (d *logDecoder) decodeRaw(r io.Reader) error {
+	br := bufio.NewReader(r)
+	for {
+		b, err := br.ReadBytes('\n')
+		if len(b) > 0 {
+			if lerr := d.write(Stdout, b); lerr != nil {
+				return lerr
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return d.flush()
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// decodeFrames frame is an 8 bytes header [stream, 0, 0, 0, size(big endian uint32)] followed by size bytes payload
+func (d *logDecoder) decodeFrames(r io.Reader) error {
+	header := make([]byte, frameHeaderSize)
+	var payload []byte
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if errors.Is(err, io.EOF) {
+				return d.flush()
 			}
-			if s, err := ParseToCombinedOutput(b); err != nil {
-				ch <- err.Error()
-				return
-			} else {
-				ch <- s
+			return err
+		}
+		size := int(binary.BigEndian.Uint32(header[4:]))
+		if cap(payload) < size {
+			payload = make([]byte, size)
+		}
+		payload = payload[:size]
+		if _, err := io.ReadFull(r, payload); err != nil {
+			return err
+		}
+
+		switch stdcopy.StdType(header[0]) {
+		case stdcopy.Stdin, stdcopy.Stdout:
+			if err := d.write(Stdout, payload); err != nil {
+				return err
 			}
-			if err == io.EOF {
-				return
+		case stdcopy.Stderr:
+			if err := d.write(Stderr, payload); err != nil {
+				return err
 			}
-			if err != nil {
-				ch <- err.Error()
-				return
+		case stdcopy.Systemerr:
+			return fmt.Errorf("error from daemon in stream: %s", payload)
+		default:
+			return fmt.Errorf("unrecognized stream: %d", header[0])
+		}
+	}
+}
+
+func (d *logDecoder) write(s Stream, b []byte) error {
+	buf, ok := d.partial[s]
+	if !ok {
+		buf = &bytes.Buffer{}
+		d.partial[s] = buf
+	}
+	buf.Write(b)
+	for {
+		i := bytes.IndexByte(buf.Bytes(), '\n')
+		if i < 0 {
+			return nil
+		}
+		line := string(buf.Next(i + 1))
+		if err := d.emit(s, strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")); err != nil {
+			return err
+		}
+	}
+}
+
+func (d *logDecoder) flush() error {
+	for _, s := range []Stream{Stdout, Stderr} {
+		if buf, ok := d.partial[s]; ok && buf.Len() > 0 {
+			if err := d.emit(s, buf.String()); err != nil {
+				return err
 			}
+			buf.Reset()
 		}
-	}()
-	return ch
+	}
+	return nil
+}
+
+func (d *logDecoder) emit(s Stream, line string) error {
+	l := LogLine{Stream: s, Line: line}
+	if d.timestamps {
+		ts, rest, _ := strings.Cut(line, " ")
+		t, err := time.Parse(time.RFC3339Nano, ts)
+		if err != nil {
+			return fmt.Errorf("parse log timestamp %q error: %w", ts, err)
+		}
+		l.Timestamp = t
+		l.Line = rest
+	}
+	select {
+	case d.ch <- l:
+		return nil
+	case <-d.ctx.Done():
+		return d.ctx.Err()
+	}
 }
@@ -0,0 +1,153 @@
+package reader
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+type frame struct {
+	stream stdcopy.StdType
+	data   string
+}
+
+func frames(fs ...frame) io.Reader {
+	var b bytes.Buffer
+	for _, f := range fs {
+		header := make([]byte, frameHeaderSize)
+		header[0] = byte(f.stream)
+		binary.BigEndian.PutUint32(header[4:], uint32(len(f.data)))
+		b.Write(header)
+		b.WriteString(f.data)
+	}
+	return &b
+}
+
+func decodeAll(r io.Reader, tty, timestamps bool) ([]LogLine, error) {
+	lines, errCh := DecodeLogStream(context.Background(), r, tty, timestamps)
+	var got []LogLine
+	for l := range lines {
+		got = append(got, l)
+	}
+	return got, <-errCh
+}
+
+func TestDecodeLogStream(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 123456789, time.UTC)
+	tests := []struct {
+		name       string
+		r          io.Reader
+		tty        bool
+		timestamps bool
+		want       []LogLine
+	}{
+		{
+			name: "header size byte equal to newline",
+			r:    frames(frame{stdcopy.Stdout, "123456789\n"}, frame{stdcopy.Stderr, strings.Repeat("x", 0x0a0a-1) + "\n"}),
+			want: []LogLine{{Stream: Stdout, Line: "123456789"}, {Stream: Stderr, Line: strings.Repeat("x", 0x0a0a-1)}},
+		},
+		{
+			name: "line split across frames",
+			r: frames(
+				frame{stdcopy.Stdout, "hel"},
+				frame{stdcopy.Stderr, "oops\n"},
+				frame{stdcopy.Stdout, "lo\nwor"},
+				frame{stdcopy.Stdout, "ld\n"},
+			),
+			want: []LogLine{{Stream: Stderr, Line: "oops"}, {Stream: Stdout, Line: "hello"}, {Stream: Stdout, Line: "world"}},
+		},
+		{
+			name: "incomplete last line is flushed",
+			r:    frames(frame{stdcopy.Stdout, "a\nb"}),
+			want: []LogLine{{Stream: Stdout, Line: "a"}, {Stream: Stdout, Line: "b"}},
+		},
+		{
+			name: "empty frame and empty line",
+			r:    frames(frame{stdcopy.Stdout, ""}, frame{stdcopy.Stdout, "\n"}),
+			want: []LogLine{{Stream: Stdout, Line: ""}},
+		},
+		{
+			name: "tty output is not multiplexed",
+			r:    strings.NewReader("a\r\n\x01\x00\x00\x00\x00\x00\x00\x02b\nc"),
+			tty:  true,
+			want: []LogLine{{Stream: Stdout, Line: "a"}, {Stream: Stdout, Line: "\x01\x00\x00\x00\x00\x00\x00\x02b"}, {Stream: Stdout, Line: "c"}},
+		},
+		{
+			name:       "timestamps",
+			r:          frames(frame{stdcopy.Stdout, "2024-01-02T03:04:05.123456789Z hello world\n"}),
+			timestamps: true,
+			want:       []LogLine{{Stream: Stdout, Timestamp: ts, Line: "hello world"}},
+		},
+		{
+			name:       "timestamps split across frames",
+			r:          frames(frame{stdcopy.Stderr, "2024-01-02T03:04"}, frame{stdcopy.Stderr, ":05.123456789Z err\n"}),
+			timestamps: true,
+			want:       []LogLine{{Stream: Stderr, Timestamp: ts, Line: "err"}},
+		},
+		{
+			name:       "timestamps with tty",
+			r:          strings.NewReader("2024-01-02T03:04:05.123456789Z tty\r\n"),
+			tty:        true,
+			timestamps: true,
+			want:       []LogLine{{Stream: Stdout, Timestamp: ts, Line: "tty"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeAll(tt.r, tt.tty, tt.timestamps)
+			if err != nil {
+				t.Fatalf("DecodeLogStream error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lines = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeLogStreamError(t *testing.T) {
+	tests := []struct {
+		name       string
+		r          io.Reader
+		timestamps bool
+		want       string
+	}{
+		{"error from daemon", frames(frame{stdcopy.Stdout, "a\n"}, frame{stdcopy.Systemerr, "boom"}), false, "error from daemon in stream: boom"},
+		{"unknown stream", frames(frame{stdcopy.StdType(7), "a\n"}), false, "unrecognized stream: 7"},
+		{"truncated payload", io.LimitReader(frames(frame{stdcopy.Stdout, "hello\n"}), frameHeaderSize+2), false, io.ErrUnexpectedEOF.Error()},
+		{"truncated header", strings.NewReader("\x01\x00\x00"), false, io.ErrUnexpectedEOF.Error()},
+		{"invalid timestamp", frames(frame{stdcopy.Stdout, "yesterday hello\n"}), true, `parse log timestamp "yesterday"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := decodeAll(tt.r, false, tt.timestamps)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeLogStreamCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	lines, errCh := DecodeLogStream(ctx, frames(frame{stdcopy.Stdout, "a\nb\n"}), false, false)
+	cancel()
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("error = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("decoder is still blocked on send after ctx is done")
+	}
+	for range lines {
+	}
+}
@@ -0,0 +1,145 @@
+package tarstream
+
+import (
+	"archive/tar"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FromFunc run write in a goroutine, the tar archive is built on the fly while reading
+// close the returned reader to stop writing early
+func FromFunc(write func(tw *tar.Writer) error) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		tw := tar.NewWriter(pw)
+		err := write(tw)
+		if err == nil {
+			err = tw.Close()
+		}
+		_ = pw.CloseWithError(err)
+	}()
+	return pr
+}
+
+func writeFile(tw *tar.Writer, h *tar.Header, open func() (io.ReadCloser, error)) error {
+	if err := tw.WriteHeader(h); err != nil {
+		return err
+	}
+	if h.Typeflag != tar.TypeReg {
+		return nil
+	}
+	r, err := open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	_, err = io.Copy(tw, r)
+	return err
+}
+
+// FromPath archive a file or directory, symlinks are kept as is except sourcePath itself, which is followed
+// if sourcePath is a file, archive contains the file only, i.e. /tmp/a.txt --> "a.txt"
+// if sourcePath is a directory, archive contains items in the directory, i.e. /tmp/dir/a.txt --> "a.txt"
+func FromPath(sourcePath string) io.ReadCloser {
+	return FromFunc(func(tw *tar.Writer) error {
+		s, err := os.Stat(sourcePath)
+		if err != nil {
+			return err
+		}
+		root := filepath.Dir(sourcePath)
+		if s.IsDir() {
+			// WalkDir does not follow a symlinked root
+			if sourcePath, err = filepath.EvalSymlinks(sourcePath); err != nil {
+				return err
+			}
+			root = sourcePath
+		}
+		return filepath.WalkDir(sourcePath, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if path == sourcePath && s.IsDir() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			if path == sourcePath {
+				// a symlinked file is archived with the content of its target
+				info = s
+			}
+			var link string
+			if info.Mode()&fs.ModeSymlink != 0 {
+				if link, err = os.Readlink(path); err != nil {
+					return err
+				}
+			}
+			h, err := tar.FileInfoHeader(info, link)
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+			h.Name = filepath.ToSlash(rel)
+			if info.IsDir() {
+				h.Name += "/"
+			}
+			return writeFile(tw, h, func() (io.ReadCloser, error) { return os.Open(path) })
+		})
+	})
+}
+
+// FromFS archive all items in fsys, item names are relative to the root of fsys
+func FromFS(fsys fs.FS) io.ReadCloser {
+	return FromFunc(func(tw *tar.Writer) error {
+		return fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if path == "." {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			// fs.FS can not read symlink target, only regular files and directories are archived
+			if !info.Mode().IsRegular() && !info.IsDir() {
+				return nil
+			}
+			h, err := tar.FileInfoHeader(info, "")
+			if err != nil {
+				return err
+			}
+			h.Name = path
+			if info.IsDir() {
+				h.Name += "/"
+			}
+			return writeFile(tw, h, func() (io.ReadCloser, error) { return fsys.Open(path) })
+		})
+	})
+}
+
+// FromBytes archive data as a single regular file
+func FromBytes(name string, data []byte, mode fs.FileMode) io.ReadCloser {
+	return FromHeader(&tar.Header{Name: name, Mode: int64(mode.Perm()), ModTime: time.Now()}, data)
+}
+
+// FromHeader archive data as a single regular file described by h, h.Size and h.Typeflag are overwritten
+func FromHeader(h *tar.Header, data []byte) io.ReadCloser {
+	return FromFunc(func(tw *tar.Writer) error {
+		h.Typeflag = tar.TypeReg
+		h.Size = int64(len(data))
+		if err := tw.WriteHeader(h); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	})
+}
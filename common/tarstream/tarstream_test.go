@@ -0,0 +1,92 @@
+package tarstream
+
+import (
+	"archive/tar"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func names(t *testing.T, r io.ReadCloser) []string {
+	t.Helper()
+	defer r.Close()
+	var n []string
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return n
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		n = append(n, h.Name)
+	}
+}
+
+func TestFromPath(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	if err := os.MkdirAll(filepath.Join(src, "sub"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("src", filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join("src", "a.txt"), filepath.Join(dir, "file-link")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		path string
+		want []string
+	}{
+		{"directory", src, []string{"a.txt", "sub/"}},
+		{"symlinked directory", filepath.Join(dir, "link"), []string{"a.txt", "sub/"}},
+		{"symlinked directory with trailing slash", filepath.Join(dir, "link") + "/", []string{"a.txt", "sub/"}},
+		{"file", filepath.Join(src, "a.txt"), []string{"a.txt"}},
+		{"symlinked file", filepath.Join(dir, "file-link"), []string{"file-link"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := names(t, FromPath(tt.path))
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("FromPath(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromPathSymlinkedFileContent(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("hello"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("a.txt", filepath.Join(dir, "link")); err != nil {
+		t.Fatal(err)
+	}
+	r := FromPath(filepath.Join(dir, "link"))
+	defer r.Close()
+	tr := tar.NewReader(r)
+	h, err := tr.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Typeflag != tar.TypeReg {
+		t.Fatalf("type = %c, want regular file", h.Typeflag)
+	}
+	b, err := io.ReadAll(tr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "hello" {
+		t.Errorf("content = %q, want %q", b, "hello")
+	}
+}
@@ -0,0 +1,111 @@
+package container
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+type AttachOption func(*types.ContainerAttachOptions)
+
+// AttachWithDetachKeys override the key sequence for detaching, i.e. "ctrl-p,ctrl-q"
+func AttachWithDetachKeys(keys string) AttachOption {
+	return func(o *types.ContainerAttachOptions) {
+		o.DetachKeys = keys
+	}
+}
+
+// AttachWithLogs replay output produced before attaching
+func AttachWithLogs() AttachOption {
+	return func(o *types.ContainerAttachOptions) {
+		o.Logs = true
+	}
+}
+
+// AttachStream an attached container, read Stdout and Stderr concurrently, otherwise one of them may block the other
+// for tty containers output is not multiplexed, all of it is in Stdout and Stderr is always empty
+type AttachStream struct {
+	// Stdin is nil if container is not created with CreateWithOpenStdin, close it to send EOF to container
+	Stdin  io.WriteCloser
+	Stdout io.Reader
+	Stderr io.Reader
+	Tty    bool
+
+	resp types.HijackedResponse
+	// pipes of demultiplexed output, nil for tty containers
+	stdout, stderr *io.PipeReader
+}
+
+// Close close the connection, container keeps running
+// unread output is discarded so that the demultiplexing goroutine is not blocked forever
+func (a *AttachStream) Close() error {
+	err := a.resp.Conn.Close()
+	if a.stdout != nil {
+		_ = a.stdout.CloseWithError(io.ErrClosedPipe)
+		_ = a.stderr.CloseWithError(io.ErrClosedPipe)
+	}
+	return err
+}
+
+type attachStdin struct {
+	resp types.HijackedResponse
+}
+
+func (s attachStdin) Write(p []byte) (int, error) {
+	return s.resp.Conn.Write(p)
+}
+
+func (s attachStdin) Close() error {
+	return s.resp.CloseWrite()
+}
+
+// Attach attach stdin, stdout and stderr of container like docker attach, container can name or id
+// use Resize to set terminal size of tty containers
+func (c ContainerClient) Attach(container string, options ...AttachOption) (*AttachStream, error) {
+	return c.AttachContext(context.Background(), container, options...)
+}
+
+func (c ContainerClient) AttachContext(ctx context.Context, container string, options ...AttachOption) (*AttachStream, error) {
+	i, _, err := c.InspectContext(ctx, container)
+	if err != nil {
+		return nil, err
+	}
+	o := types.ContainerAttachOptions{Stream: true, Stdin: i.Config.OpenStdin, Stdout: true, Stderr: true}
+	for _, option := range options {
+		option(&o)
+	}
+	r, err := c.c.ContainerAttach(ctx, container, o)
+	if err != nil {
+		return nil, err
+	}
+	a := &AttachStream{Tty: i.Config.Tty, resp: r}
+	if o.Stdin {
+		a.Stdin = attachStdin{resp: r}
+	}
+	if i.Config.Tty {
+		a.Stdout, a.Stderr = r.Reader, strings.NewReader("")
+		return a, nil
+	}
+	stdoutR, stdoutW := io.Pipe()
+	stderrR, stderrW := io.Pipe()
+	go func() {
+		_, err := stdcopy.StdCopy(stdoutW, stderrW, r.Reader)
+		_ = stdoutW.CloseWithError(err)
+		_ = stderrW.CloseWithError(err)
+	}()
+	a.Stdout, a.Stderr = stdoutR, stderrR
+	a.stdout, a.stderr = stdoutR, stderrR
+	return a, nil
+}
+
+// Resize set terminal size of a tty container, container can name or id
+func (c ContainerClient) Resize(container string, height, width uint) error {
+	return c.ResizeContext(context.Background(), container, height, width)
+}
+
+func (c ContainerClient) ResizeContext(ctx context.Context, container string, height, width uint) error {
+	return c.c.ContainerResize(ctx, container, types.ResizeOptions{Height: height, Width: width})
+}
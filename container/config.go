@@ -0,0 +1,114 @@
+package container
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/go-connections/nat"
+)
+
+// createConfigFromInspect build create config which recreates container, it is shared by Recreate and GenerateRunCommand
+// values inherited from image img and values set by the daemon are dropped, so that defaults of the image apply
+// anonymous volumes are mounted by name, so that data in them is kept
+func createConfigFromInspect(i types.ContainerJSON, img *container.Config) *ContainerCreateConfig {
+	cfg := *i.Config
+	hc := *i.HostConfig
+
+	// default hostname is the short container id, or the hostname of the host or joined container
+	if strings.HasPrefix(i.ID, cfg.Hostname) || hc.NetworkMode.IsHost() || hc.NetworkMode.IsContainer() {
+		cfg.Hostname = ""
+	}
+	cfg.Env = slices.DeleteFunc(slices.Clone(cfg.Env), func(e string) bool { return slices.Contains(img.Env, e) })
+	switch {
+	case slices.Equal(cfg.Entrypoint, img.Entrypoint):
+		cfg.Entrypoint = nil
+		if slices.Equal(cfg.Cmd, img.Cmd) {
+			cfg.Cmd = nil
+		}
+	case len(cfg.Entrypoint) == 0:
+		// entrypoint of image is cleared explicitly, an empty entrypoint would fall back to the image one
+		cfg.Entrypoint = []string{""}
+	}
+	if cfg.User == img.User {
+		cfg.User = ""
+	}
+	if cfg.WorkingDir == img.WorkingDir {
+		cfg.WorkingDir = ""
+	}
+	if cfg.StopSignal == img.StopSignal {
+		cfg.StopSignal = ""
+	}
+	if reflect.DeepEqual(cfg.Healthcheck, img.Healthcheck) {
+		cfg.Healthcheck = nil
+	}
+	cfg.Labels = make(map[string]string)
+	for k, v := range i.Config.Labels {
+		if iv, ok := img.Labels[k]; !ok || iv != v {
+			cfg.Labels[k] = v
+		}
+	}
+	cfg.ExposedPorts = nil
+	for p := range i.Config.ExposedPorts {
+		if _, ok := img.ExposedPorts[p]; !ok {
+			if cfg.ExposedPorts == nil {
+				cfg.ExposedPorts = make(nat.PortSet)
+			}
+			cfg.ExposedPorts[p] = struct{}{}
+		}
+	}
+	// anonymous volumes are not in binds or mounts of host config, mount them by name
+	hc.Mounts = slices.Clone(hc.Mounts)
+	for _, m := range i.Mounts {
+		if m.Type == mount.TypeVolume && !hasMountTarget(&hc, m.Destination) {
+			hc.Mounts = append(hc.Mounts, mount.Mount{Type: mount.TypeVolume, Source: m.Name, Target: m.Destination, ReadOnly: !m.RW})
+		}
+	}
+	cfg.Volumes = nil
+	for v := range i.Config.Volumes {
+		if _, ok := img.Volumes[v]; !ok && !hasMountTarget(&hc, v) {
+			if cfg.Volumes == nil {
+				cfg.Volumes = make(map[string]struct{})
+			}
+			cfg.Volumes[v] = struct{}{}
+		}
+	}
+
+	nc := &network.NetworkingConfig{EndpointsConfig: make(map[string]*network.EndpointSettings)}
+	if i.NetworkSettings != nil {
+		name := strings.TrimPrefix(i.Name, "/")
+		for n, e := range i.NetworkSettings.Networks {
+			// the network of a builtin mode is implied by the mode, user networks are connected in addition
+			if !hc.NetworkMode.IsUserDefined() && (n == hc.NetworkMode.NetworkName() || n == "bridge") {
+				continue
+			}
+			settings := &network.EndpointSettings{IPAMConfig: e.IPAMConfig, Links: e.Links, DriverOpts: e.DriverOpts}
+			// the daemon adds container short id and name as aliases automatically
+			for _, a := range e.Aliases {
+				if !strings.HasPrefix(i.ID, a) && a != name {
+					settings.Aliases = append(settings.Aliases, a)
+				}
+			}
+			nc.EndpointsConfig[n] = settings
+		}
+	}
+	return &ContainerCreateConfig{Config: &cfg, HostConfig: &hc, NetworkConfig: nc}
+}
+
+func hasMountTarget(hc *container.HostConfig, target string) bool {
+	for _, m := range hc.Mounts {
+		if m.Target == target {
+			return true
+		}
+	}
+	for _, b := range hc.Binds {
+		if parts := strings.Split(b, ":"); len(parts) > 1 && parts[1] == target {
+			return true
+		}
+	}
+	return false
+}
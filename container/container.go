@@ -1,11 +1,15 @@
 package container
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -15,67 +19,107 @@ import (
 
 	"github.com/riete/docker/common/filter"
 	"github.com/riete/docker/common/reader"
+	"github.com/riete/docker/common/tarstream"
 
 	"github.com/riete/archive/tar"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
 )
 
+const execInspectInterval = 100 * time.Millisecond
+
 type ContainerClient struct {
 	c *client.Client
 }
 
 func (c ContainerClient) List(options ...ListOption) ([]types.Container, error) {
+	return c.ListContext(context.Background(), options...)
+}
+
+func (c ContainerClient) ListContext(ctx context.Context, options ...ListOption) ([]types.Container, error) {
 	o := types.ContainerListOptions{}
 	for _, option := range options {
 		option(&o)
 	}
-	return c.c.ContainerList(context.Background(), o)
+	if err := filter.Validate(filter.ContainerList, o.Filters); err != nil {
+		return nil, err
+	}
+	return c.c.ContainerList(ctx, o)
 }
 
 // Inspect container can name or id
 func (c ContainerClient) Inspect(container string) (types.ContainerJSON, string, error) {
-	r, b, err := c.c.ContainerInspectWithRaw(context.Background(), container, false)
+	return c.InspectContext(context.Background(), container)
+}
+
+func (c ContainerClient) InspectContext(ctx context.Context, container string) (types.ContainerJSON, string, error) {
+	r, b, err := c.c.ContainerInspectWithRaw(ctx, container, false)
 	return r, str.FromBytes(b), err
 }
 
 // Start container can name or id
 func (c ContainerClient) Start(container string) error {
-	return c.c.ContainerStart(context.Background(), container, types.ContainerStartOptions{})
+	return c.StartContext(context.Background(), container)
+}
+
+func (c ContainerClient) StartContext(ctx context.Context, container string) error {
+	return c.c.ContainerStart(ctx, container, types.ContainerStartOptions{})
 }
 
 // Stop target can name or id
 func (c ContainerClient) Stop(target string, option TimeoutOption) error {
+	return c.StopContext(context.Background(), target, option)
+}
+
+func (c ContainerClient) StopContext(ctx context.Context, target string, option TimeoutOption) error {
 	o := container.StopOptions{}
 	option(&o)
-	return c.c.ContainerStop(context.Background(), target, o)
+	return c.c.ContainerStop(ctx, target, o)
 }
 
 // Restart target can name or id
 func (c ContainerClient) Restart(target string, option TimeoutOption) error {
+	return c.RestartContext(context.Background(), target, option)
+}
+
+func (c ContainerClient) RestartContext(ctx context.Context, target string, option TimeoutOption) error {
 	o := container.StopOptions{}
 	option(&o)
-	return c.c.ContainerRestart(context.Background(), target, o)
+	return c.c.ContainerRestart(ctx, target, o)
 }
 
 // Rename container can name or id
 func (c ContainerClient) Rename(container, newName string) error {
-	return c.c.ContainerRename(context.Background(), container, newName)
+	return c.RenameContext(context.Background(), container, newName)
+}
+
+func (c ContainerClient) RenameContext(ctx context.Context, container, newName string) error {
+	return c.c.ContainerRename(ctx, container, newName)
 }
 
 // Remove container can name or id
 func (c ContainerClient) Remove(container string, options ...RemoveOption) error {
+	return c.RemoveContext(context.Background(), container, options...)
+}
+
+func (c ContainerClient) RemoveContext(ctx context.Context, container string, options ...RemoveOption) error {
 	o := types.ContainerRemoveOptions{}
 	for _, option := range options {
 		option(&o)
 	}
-	return c.c.ContainerRemove(context.Background(), container, o)
+	return c.c.ContainerRemove(ctx, container, o)
 }
 
-// Stats container can name or id
+// Stats container can name or id, return raw json, use StatsOneShot or StatsStream for typed samples
 func (c ContainerClient) Stats(container string) (string, error) {
-	r, err := c.c.ContainerStatsOneShot(context.Background(), container)
+	return c.StatsContext(context.Background(), container)
+}
+
+func (c ContainerClient) StatsContext(ctx context.Context, container string) (string, error) {
+	r, err := c.c.ContainerStatsOneShot(ctx, container)
 	if err != nil {
 		return "", err
 	}
@@ -88,7 +132,11 @@ func (c ContainerClient) Stats(container string) (string, error) {
 // targetPath is path to save copied file, if unpack is false, save as targetPath/{sourcePath.PathStat.Name}.tar
 // if unpack is true, will unpack items to targetPath
 func (c ContainerClient) CopyFrom(container, sourcePath, targetPath string, unpack bool) error {
-	r, s, err := c.c.CopyFromContainer(context.Background(), container, sourcePath)
+	return c.CopyFromContext(context.Background(), container, sourcePath, targetPath, unpack)
+}
+
+func (c ContainerClient) CopyFromContext(ctx context.Context, container, sourcePath, targetPath string, unpack bool) error {
+	r, s, err := c.c.CopyFromContainer(ctx, container, sourcePath)
 	if err != nil {
 		return err
 	}
@@ -112,74 +160,99 @@ func (c ContainerClient) CopyFrom(container, sourcePath, targetPath string, unpa
 
 // CopyFromRaw return tar archived as io.ReadCloser
 func (c ContainerClient) CopyFromRaw(container, path string) (io.ReadCloser, types.ContainerPathStat, error) {
-	return c.c.CopyFromContainer(context.Background(), container, path)
+	return c.CopyFromRawContext(context.Background(), container, path)
+}
+
+func (c ContainerClient) CopyFromRawContext(ctx context.Context, container, path string) (io.ReadCloser, types.ContainerPathStat, error) {
+	return c.c.CopyFromContainer(ctx, container, path)
 }
 
 // CopyTo sourcePath is file/folder path to be copied, container can name or id
 // targetPath is a directory path in container(create if not exists)
-// sourcePath first be archived as a tar file, then copy to container targetPath and extract it
+// sourcePath is archived as a tar stream on the fly, then copied to container targetPath and extracted
 func (c ContainerClient) CopyTo(sourcePath, container, targetPath string, options ...CopyToOption) error {
+	return c.CopyToContext(context.Background(), sourcePath, container, targetPath, options...)
+}
+
+func (c ContainerClient) CopyToContext(ctx context.Context, sourcePath, container, targetPath string, options ...CopyToOption) error {
+	if _, err := os.Stat(sourcePath); err != nil {
+		return err
+	}
+	r := tarstream.FromPath(sourcePath)
+	defer r.Close()
+	return c.CopyTarToContext(ctx, r, container, targetPath, options...)
+}
+
+// CopyTarTo r is a tar archive, it is extracted to targetPath(create if not exists) in container
+func (c ContainerClient) CopyTarTo(r io.Reader, container, targetPath string, options ...CopyToOption) error {
+	return c.CopyTarToContext(context.Background(), r, container, targetPath, options...)
+}
+
+func (c ContainerClient) CopyTarToContext(ctx context.Context, r io.Reader, container, targetPath string, options ...CopyToOption) error {
 	o := types.CopyToContainerOptions{}
 	for _, option := range options {
 		option(&o)
 	}
-	s, err := os.Stat(sourcePath)
-	if err != nil {
+	if err := c.ensureDir(ctx, container, targetPath); err != nil {
 		return err
 	}
+	return c.c.CopyToContainer(ctx, container, targetPath, r, o)
+}
 
-	if tStat, found, err := c.PathStat(container, targetPath); !found {
-		_, stderr, err := c.ExecOneShot(container, fmt.Sprintf("mkdir -p %s", targetPath))
-		if err != nil {
-			return err
-		}
-		if stderr != "" {
-			return fmt.Errorf("create directory %s error: %s", targetPath, stderr)
+// CopyBytesTo write data as file targetPath/name in container
+func (c ContainerClient) CopyBytesTo(data []byte, name string, mode fs.FileMode, container, targetPath string, options ...CopyToOption) error {
+	return c.CopyBytesToContext(context.Background(), data, name, mode, container, targetPath, options...)
+}
+
+func (c ContainerClient) CopyBytesToContext(ctx context.Context, data []byte, name string, mode fs.FileMode, container, targetPath string, options ...CopyToOption) error {
+	r := tarstream.FromBytes(name, data, mode)
+	defer r.Close()
+	return c.CopyTarToContext(ctx, r, container, targetPath, options...)
+}
+
+// CopyFSTo copy all items in fsys to targetPath in container, i.e. an embed.FS
+func (c ContainerClient) CopyFSTo(fsys fs.FS, container, targetPath string, options ...CopyToOption) error {
+	return c.CopyFSToContext(context.Background(), fsys, container, targetPath, options...)
+}
+
+func (c ContainerClient) CopyFSToContext(ctx context.Context, fsys fs.FS, container, targetPath string, options ...CopyToOption) error {
+	r := tarstream.FromFS(fsys)
+	defer r.Close()
+	return c.CopyTarToContext(ctx, r, container, targetPath, options...)
+}
+
+// ensureDir create directory path in container if not exists
+func (c ContainerClient) ensureDir(ctx context.Context, container, path string) error {
+	if stat, found, err := c.PathStatContext(ctx, container, path); !found {
+		if _, err := c.ExecCommandContext(ctx, container, []string{"mkdir", "-p", path}, nil); err != nil {
+			return fmt.Errorf("create directory %s error: %w", path, err)
 		}
 	} else if err != nil {
 		return err
-	} else if !tStat.Mode.IsDir() {
-		return fmt.Errorf("%s is not a directory", targetPath)
+	} else if !stat.Mode.IsDir() {
+		return fmt.Errorf("%s is not a directory", path)
 	}
-
-	tarFile := filepath.Join(os.TempDir(), fmt.Sprintf("%d.tar", time.Now().Unix()))
-	cwd := filepath.Dir(sourcePath)
-	source := filepath.Base(sourcePath)
-	if s.IsDir() {
-		cwd = sourcePath
-		source = "."
-	}
-	t := tar.NewTarPacker(tarFile, cwd, false, source)
-	if err := t.Pack(); err != nil {
-		return err
-	}
-	defer os.Remove(tarFile)
-	r, err := os.Open(tarFile)
-	if err != nil {
-		return err
-	}
-	defer r.Close()
-	return c.c.CopyToContainer(context.Background(), container, targetPath, r, o)
+	return nil
 }
 
-func (c ContainerClient) execCreate(container string, options ...ExecConfigOptions) (string, error) {
+func (c ContainerClient) execCreate(ctx context.Context, container string, options ...ExecConfigOptions) (string, error) {
 	o := types.ExecConfig{AttachStdout: true, AttachStdin: true, AttachStderr: true, Cmd: []string{"bash"}}
 	for _, option := range options {
 		option(&o)
 	}
-	r, err := c.c.ContainerExecCreate(context.Background(), container, o)
+	r, err := c.c.ContainerExecCreate(ctx, container, o)
 	if err != nil {
 		return "", err
 	}
 	return r.ID, nil
 }
 
-func (c ContainerClient) exec(execId string, options ...ExecStartOption) (types.HijackedResponse, error) {
+func (c ContainerClient) exec(ctx context.Context, execId string, options ...ExecStartOption) (types.HijackedResponse, error) {
 	o := types.ExecStartCheck{}
 	for _, option := range options {
 		option(&o)
 	}
-	return c.c.ContainerExecAttach(context.Background(), execId, o)
+	return c.c.ContainerExecAttach(ctx, execId, o)
 }
 
 // Exec like docker exec -it [container] bash, open an interactive connection, container can name or id
@@ -188,12 +261,16 @@ func (c ContainerClient) exec(execId string, options ...ExecStartOption) (types.
 // types.HijackedResponse.Reader receive data
 // call types.HijackedResponse.Close() to close connection
 func (c ContainerClient) Exec(container string, options ...ExecConfigOptions) (string, types.HijackedResponse, error) {
+	return c.ExecContext(context.Background(), container, options...)
+}
+
+func (c ContainerClient) ExecContext(ctx context.Context, container string, options ...ExecConfigOptions) (string, types.HijackedResponse, error) {
 	options = append(options, ExecConfigWithTty())
-	execId, err := c.execCreate(container, options...)
+	execId, err := c.execCreate(ctx, container, options...)
 	if err != nil {
 		return execId, types.HijackedResponse{}, err
 	}
-	if r, err := c.exec(execId, ExecStartWithTty()); err != nil {
+	if r, err := c.exec(ctx, execId, ExecStartWithTty()); err != nil {
 		return execId, types.HijackedResponse{}, err
 	} else {
 		return execId, r, nil
@@ -201,21 +278,29 @@ func (c ContainerClient) Exec(container string, options ...ExecConfigOptions) (s
 }
 
 func (c ContainerClient) ExecResizePty(execId string, height, width uint) error {
+	return c.ExecResizePtyContext(context.Background(), execId, height, width)
+}
+
+func (c ContainerClient) ExecResizePtyContext(ctx context.Context, execId string, height, width uint) error {
 	o := types.ResizeOptions{Height: height, Width: width}
-	return c.c.ContainerExecResize(context.Background(), execId, o)
+	return c.c.ContainerExecResize(ctx, execId, o)
 }
 
-func (c ContainerClient) execOneShot(container string, options ...ExecConfigOptions) (types.HijackedResponse, error) {
-	execId, err := c.execCreate(container, options...)
+func (c ContainerClient) execOneShot(ctx context.Context, container string, options ...ExecConfigOptions) (types.HijackedResponse, error) {
+	execId, err := c.execCreate(ctx, container, options...)
 	if err != nil {
 		return types.HijackedResponse{}, err
 	}
-	return c.exec(execId)
+	return c.exec(ctx, execId)
 }
 
 // ExecOneShot run cmd once and return stdout and stderr, container can name or id
 func (c ContainerClient) ExecOneShot(container string, cmd string, options ...ExecConfigOptions) (string, string, error) {
-	if r, err := c.execOneShot(container, options...); err != nil {
+	return c.ExecOneShotContext(context.Background(), container, cmd, options...)
+}
+
+func (c ContainerClient) ExecOneShotContext(ctx context.Context, container string, cmd string, options ...ExecConfigOptions) (string, string, error) {
+	if r, err := c.execOneShot(ctx, container, options...); err != nil {
 		return "", "", err
 	} else {
 		defer r.Close()
@@ -231,7 +316,11 @@ func (c ContainerClient) ExecOneShot(container string, cmd string, options ...Ex
 
 // ExecOneShotWithCombinedOutput run cmd once and return combined stdout and stderr
 func (c ContainerClient) ExecOneShotWithCombinedOutput(container string, cmd string, options ...ExecConfigOptions) (string, error) {
-	if r, err := c.execOneShot(container, options...); err != nil {
+	return c.ExecOneShotWithCombinedOutputContext(context.Background(), container, cmd, options...)
+}
+
+func (c ContainerClient) ExecOneShotWithCombinedOutputContext(ctx context.Context, container string, cmd string, options ...ExecConfigOptions) (string, error) {
+	if r, err := c.execOneShot(ctx, container, options...); err != nil {
 		return "", err
 	} else {
 		defer r.Close()
@@ -245,40 +334,151 @@ func (c ContainerClient) ExecOneShotWithCombinedOutput(container string, cmd str
 	}
 }
 
+// ExecCommand run cmd argv directly(no shell) and wait for it to exit, container can name or id
+// stdin is optional, if not nil it is copied to the process and closed when fully copied
+// a non-zero exit code is returned as *ExitError, ExecResult is filled in that case too
+func (c ContainerClient) ExecCommand(container string, cmd []string, stdin io.Reader, options ...ExecConfigOptions) (ExecResult, error) {
+	return c.ExecCommandContext(context.Background(), container, cmd, stdin, options...)
+}
+
+func (c ContainerClient) ExecCommandContext(ctx context.Context, container string, cmd []string, stdin io.Reader, options ...ExecConfigOptions) (ExecResult, error) {
+	o := types.ExecConfig{AttachStdout: true, AttachStderr: true, AttachStdin: stdin != nil}
+	for _, option := range options {
+		option(&o)
+	}
+	o.Cmd = cmd
+	o.Detach = false
+	e, err := c.c.ContainerExecCreate(ctx, container, o)
+	if err != nil {
+		return ExecResult{}, err
+	}
+	r, err := c.c.ContainerExecAttach(ctx, e.ID, types.ExecStartCheck{Tty: o.Tty})
+	if err != nil {
+		return ExecResult{}, err
+	}
+	defer r.Close()
+
+	// hijacked connection does not watch ctx, close it to unblock reading
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			r.Close()
+		case <-done:
+		}
+	}()
+
+	if stdin != nil {
+		go func() {
+			_, _ = io.Copy(r.Conn, stdin)
+			_ = r.CloseWrite()
+		}()
+	}
+
+	var stdout, stderr bytes.Buffer
+	if o.Tty {
+		_, err = io.Copy(&stdout, r.Reader)
+	} else {
+		_, err = stdcopy.StdCopy(&stdout, &stderr, r.Reader)
+	}
+	if ctx.Err() != nil {
+		return ExecResult{}, ctx.Err()
+	}
+	if err != nil {
+		return ExecResult{}, err
+	}
+
+	result := ExecResult{ExecId: e.ID, Stdout: stdout.String(), Stderr: stderr.String()}
+	if result.ExitCode, err = c.execExitCode(ctx, e.ID); err != nil {
+		return result, err
+	}
+	if result.ExitCode != 0 {
+		return result, &ExitError{ExitCode: result.ExitCode, Stderr: result.Stderr}
+	}
+	return result, nil
+}
+
+// execExitCode output stream may end slightly before the daemon marks exec as exited
+func (c ContainerClient) execExitCode(ctx context.Context, execId string) (int, error) {
+	for {
+		r, err := c.c.ContainerExecInspect(ctx, execId)
+		if err != nil {
+			return 0, err
+		}
+		if !r.Running {
+			return r.ExitCode, nil
+		}
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(execInspectInterval):
+		}
+	}
+}
+
 // PathStat return path stat in target container
 func (c ContainerClient) PathStat(container, path string) (types.ContainerPathStat, bool, error) {
-	r, err := c.c.ContainerStatPath(context.Background(), container, path)
+	return c.PathStatContext(context.Background(), container, path)
+}
+
+func (c ContainerClient) PathStatContext(ctx context.Context, container, path string) (types.ContainerPathStat, bool, error) {
+	r, err := c.c.ContainerStatPath(ctx, container, path)
 	return r, !client.IsErrNotFound(err), err
 }
 
 // Prune remove unused(not running) container
 func (c ContainerClient) Prune(options ...PruneOption) (types.ContainersPruneReport, error) {
+	return c.PruneContext(context.Background(), options...)
+}
+
+func (c ContainerClient) PruneContext(ctx context.Context, options ...PruneOption) (types.ContainersPruneReport, error) {
 	f := make(map[string]string)
 	for _, option := range options {
 		option(f)
 	}
-	r, err := c.c.ContainersPrune(context.Background(), filter.NewFilterArgs(f))
+	r, err := c.c.ContainersPrune(ctx, filter.NewFilterArgs(f))
 	return r, err
 }
 
+// PruneByFilter prune with filters built by filter.New, a key can have multiple values, i.e. several label filters
+func (c ContainerClient) PruneByFilter(args filters.Args) (types.ContainersPruneReport, error) {
+	return c.PruneByFilterContext(context.Background(), args)
+}
+
+func (c ContainerClient) PruneByFilterContext(ctx context.Context, args filters.Args) (types.ContainersPruneReport, error) {
+	if err := filter.Validate(filter.ContainerPrune, args); err != nil {
+		return types.ContainersPruneReport{}, err
+	}
+	return c.c.ContainersPrune(ctx, args)
+}
+
 // Commit create image from container，default is pause the container before committing
 func (c ContainerClient) Commit(container, image string, options ...CommitOption) (string, error) {
+	return c.CommitContext(context.Background(), container, image, options...)
+}
+
+func (c ContainerClient) CommitContext(ctx context.Context, container, image string, options ...CommitOption) (string, error) {
 	o := types.ContainerCommitOptions{Reference: image, Pause: true}
 	for _, option := range options {
 		option(&o)
 	}
-	r, err := c.c.ContainerCommit(context.Background(), container, o)
+	r, err := c.c.ContainerCommit(ctx, container, o)
 	return r.ID, err
 }
 
 // Export export container filesystem as a tar file
 func (c ContainerClient) Export(container, path string) error {
+	return c.ExportContext(context.Background(), container, path)
+}
+
+func (c ContainerClient) ExportContext(ctx context.Context, container, path string) error {
 	w, err := os.Create(path)
 	if err != nil {
 		return err
 	}
 	defer w.Close()
-	r, err := c.c.ContainerExport(context.Background(), container)
+	r, err := c.c.ContainerExport(ctx, container)
 	if err != nil {
 		return err
 	}
@@ -287,64 +487,250 @@ func (c ContainerClient) Export(container, path string) error {
 	return err
 }
 
+// Signal send signal to container, sig can be name or number, i.e. "SIGHUP", "HUP" or "1"
+func (c ContainerClient) Signal(container, sig string) error {
+	return c.SignalContext(context.Background(), container, sig)
+}
+
+func (c ContainerClient) SignalContext(ctx context.Context, container, sig string) error {
+	return c.c.ContainerKill(ctx, container, sig)
+}
+
 // Kill send SIGKILL signal to container
 func (c ContainerClient) Kill(container string) error {
-	return c.c.ContainerKill(context.Background(), container, "SIGKILL")
+	return c.KillContext(context.Background(), container)
+}
+
+func (c ContainerClient) KillContext(ctx context.Context, container string) error {
+	return c.SignalContext(ctx, container, "SIGKILL")
 }
 
 // Terminate send SIGTERM signal to container
 func (c ContainerClient) Terminate(container string) error {
-	return c.c.ContainerKill(context.Background(), container, "SIGTERM")
+	return c.TerminateContext(context.Background(), container)
 }
 
-// Logs return container logs as io.ReadCloser, can user reader.ParseToCombinedStreamOutput to get log message
+func (c ContainerClient) TerminateContext(ctx context.Context, container string) error {
+	return c.SignalContext(ctx, container, "SIGTERM")
+}
+
+// Logs return container logs as io.ReadCloser, use reader.DecodeLogStream to get log lines
 func (c ContainerClient) Logs(container string, options ...LogsOption) (io.ReadCloser, error) {
+	return c.LogsContext(context.Background(), container, options...)
+}
+
+func (c ContainerClient) LogsContext(ctx context.Context, container string, options ...LogsOption) (io.ReadCloser, error) {
 	o := types.ContainerLogsOptions{ShowStderr: true, ShowStdout: true}
 	for _, option := range options {
 		option(&o)
 	}
-	return c.c.ContainerLogs(context.Background(), container, o)
+	return c.c.ContainerLogs(ctx, container, o)
 }
 
 func (c ContainerClient) Pause(container string) error {
-	return c.c.ContainerPause(context.Background(), container)
+	return c.PauseContext(context.Background(), container)
+}
+
+func (c ContainerClient) PauseContext(ctx context.Context, container string) error {
+	return c.c.ContainerPause(ctx, container)
 }
 
 func (c ContainerClient) Unpause(container string) error {
-	return c.c.ContainerUnpause(context.Background(), container)
+	return c.UnpauseContext(context.Background(), container)
+}
+
+func (c ContainerClient) UnpauseContext(ctx context.Context, container string) error {
+	return c.c.ContainerUnpause(ctx, container)
 }
 
 // Process processes info in container, ps -ef
 func (c ContainerClient) Process(container string) (container.ContainerTopOKBody, error) {
-	return c.c.ContainerTop(context.Background(), container, nil)
+	return c.ProcessContext(context.Background(), container)
 }
 
-// Create container create, set replace to true to remove before create
-func (c ContainerClient) Create(image, container string, replace bool, options ...CreateOption) (container.CreateResponse, error) {
-	if replace {
-		_ = c.Remove(container, RemoveWithForce())
+func (c ContainerClient) ProcessContext(ctx context.Context, container string) (container.ContainerTopOKBody, error) {
+	return c.c.ContainerTop(ctx, container, nil)
+}
+
+// Update change resource limits and restart policy of a running or stopped container, return daemon warnings
+func (c ContainerClient) Update(container string, options ...UpdateOption) ([]string, error) {
+	return c.UpdateContext(context.Background(), container, options...)
+}
+
+func (c ContainerClient) UpdateContext(ctx context.Context, target string, options ...UpdateOption) ([]string, error) {
+	o := container.UpdateConfig{}
+	for _, option := range options {
+		option(&o)
 	}
+	r, err := c.c.ContainerUpdate(ctx, target, o)
+	return r.Warnings, err
+}
+
+// Health return current health status and the last n probe results, n <= 0 return all kept by the daemon
+func (c ContainerClient) Health(container string, n int) (HealthState, error) {
+	return c.HealthContext(context.Background(), container, n)
+}
+
+func (c ContainerClient) HealthContext(ctx context.Context, container string, n int) (HealthState, error) {
+	i, _, err := c.InspectContext(ctx, container)
+	if err != nil {
+		return HealthState{}, err
+	}
+	if i.State == nil || i.State.Health == nil {
+		return HealthState{Status: types.NoHealthcheck}, nil
+	}
+	h := HealthState{Status: i.State.Health.Status, FailingStreak: i.State.Health.FailingStreak, Log: i.State.Health.Log}
+	if n > 0 && len(h.Log) > n {
+		h.Log = h.Log[len(h.Log)-n:]
+	}
+	return h, nil
+}
+
+// Create container create, set replace to true to replace the existing container of the same name
+// the existing one is only stopped and removed after the new one is created as "<name>-new-<random>" successfully
+// errors are *ReplaceError if replace is true
+func (c ContainerClient) Create(image, container string, replace bool, options ...CreateOption) (container.CreateResponse, error) {
+	return c.CreateContext(context.Background(), image, container, replace, options...)
+}
 
+func (c ContainerClient) CreateContext(ctx context.Context, image, name string, replace bool, options ...CreateOption) (container.CreateResponse, error) {
 	o := NewContainerCreateConfig()
 	for _, option := range options {
 		option(o)
 	}
 	o.Config.Image = image
-	return c.c.ContainerCreate(context.Background(), o.Config, o.HostConfig, o.NetworkConfig, o.Platform, container)
+	if err := o.Validate(); err != nil {
+		if replace {
+			return container.CreateResponse{}, &ReplaceError{Step: ReplaceStepValidate, Container: name, Err: err}
+		}
+		return container.CreateResponse{}, err
+	}
+	if replace {
+		return c.replace(ctx, name, o)
+	}
+	return c.create(ctx, name, o)
+}
+
+// create container from validated config, networks beyond the primary one are connected after creation
+// the container is removed if any network fails to connect
+func (c ContainerClient) create(ctx context.Context, name string, o *ContainerCreateConfig) (container.CreateResponse, error) {
+	primary, others := o.splitNetworks()
+	r, err := c.c.ContainerCreate(ctx, o.Config, o.HostConfig, primary, o.Platform, name)
+	if err != nil {
+		return r, err
+	}
+	names := make([]string, 0, len(others))
+	for n := range others {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		if err = c.c.NetworkConnect(ctx, n, r.ID, others[n]); err != nil {
+			_ = c.Remove(r.ID, RemoveWithForce())
+			return r, fmt.Errorf("connect network %s error: %w", n, err)
+		}
+	}
+	return r, nil
 }
 
 // Run create container and start it
 func (c ContainerClient) Run(image, container string, replace bool, options ...CreateOption) (container.CreateResponse, error) {
-	r, err := c.Create(image, container, replace, options...)
+	return c.RunContext(context.Background(), image, container, replace, options...)
+}
+
+func (c ContainerClient) RunContext(ctx context.Context, image, container string, replace bool, options ...CreateOption) (container.CreateResponse, error) {
+	r, err := c.CreateContext(ctx, image, container, replace, options...)
 	if err != nil {
 		return r, err
 	}
-	return r, c.Start(container)
+	return r, c.StartContext(ctx, container)
+}
+
+// Wait block until target reach the condition and return its exit code, target can name or id
+func (c ContainerClient) Wait(target string, condition WaitCondition) (int64, error) {
+	return c.WaitContext(context.Background(), target, condition)
+}
+
+func (c ContainerClient) WaitContext(ctx context.Context, target string, condition WaitCondition) (int64, error) {
+	statusCh, errCh := c.c.ContainerWait(ctx, target, container.WaitCondition(condition))
+	return waitStatus(statusCh, errCh)
+}
+
+func waitStatus(statusCh <-chan container.WaitResponse, errCh <-chan error) (int64, error) {
+	select {
+	case s := <-statusCh:
+		if s.Error != nil {
+			return s.StatusCode, errors.New(s.Error.Message)
+		}
+		return s.StatusCode, nil
+	case err := <-errCh:
+		return 0, err
+	}
+}
+
+// RunAndWait create container, start it and wait for it to exit, a non-zero exit code is not an error
+// stdout and stderr are captured from container logs, they are empty if container created with CreateWithAutoRemove
+// if ctx is canceled the container is stopped, set remove to true to remove the container when done or canceled
+func (c ContainerClient) RunAndWait(image, name string, replace, remove bool, options ...CreateOption) (RunResult, error) {
+	return c.RunAndWaitContext(context.Background(), image, name, replace, remove, options...)
+}
+
+func (c ContainerClient) RunAndWaitContext(ctx context.Context, image, name string, replace, remove bool, options ...CreateOption) (RunResult, error) {
+	r, err := c.CreateContext(ctx, image, name, replace, options...)
+	if err != nil {
+		return RunResult{}, err
+	}
+	result := RunResult{ContainerId: r.ID}
+	if remove {
+		// ctx may be canceled already, cleanup must not depend on it
+		defer c.Remove(r.ID, RemoveWithForce())
+	}
+
+	// register wait before start, otherwise a short-lived container may exit before waiting
+	statusCh, errCh := c.c.ContainerWait(ctx, r.ID, container.WaitConditionNextExit)
+	start := time.Now()
+	if err = c.StartContext(ctx, r.ID); err != nil {
+		return result, err
+	}
+	result.ExitCode, err = waitStatus(statusCh, errCh)
+	result.Duration = time.Since(start)
+	if err != nil {
+		if ctx.Err() != nil {
+			_ = c.Stop(r.ID, StopWithDefaultTimeout())
+		}
+		return result, err
+	}
+
+	i, _, err := c.InspectContext(ctx, r.ID)
+	if client.IsErrNotFound(err) {
+		return result, nil
+	} else if err != nil {
+		return result, err
+	}
+	logs, err := c.LogsContext(ctx, r.ID)
+	if err != nil {
+		return result, err
+	}
+	defer logs.Close()
+	if i.Config.Tty {
+		var b bytes.Buffer
+		_, err = io.Copy(&b, logs)
+		result.Stdout = b.String()
+		return result, err
+	}
+	result.Stdout, result.Stderr, err = reader.ParseToStdoutStderr(logs)
+	return result, err
 }
 
 func NewContainerClient() (*ContainerClient, error) {
-	var err error
-	c := &ContainerClient{}
-	c.c, err = client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
-	return c, err
+	c, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, err
+	}
+	return NewContainerClientFromClient(c), nil
+}
+
+// NewContainerClientFromClient wrap an existing engine client, the connection is shared and not owned by ContainerClient
+func NewContainerClientFromClient(c *client.Client) *ContainerClient {
+	return &ContainerClient{c: c}
 }
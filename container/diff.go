@@ -0,0 +1,156 @@
+package container
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+
+	"github.com/riete/docker/common/tarstream"
+)
+
+type ChangeKind string
+
+const (
+	ChangeAdded    ChangeKind = "added"
+	ChangeModified ChangeKind = "modified"
+	ChangeDeleted  ChangeKind = "deleted"
+)
+
+// Change a changed path in container filesystem relative to its image
+type Change struct {
+	Kind ChangeKind
+	Path string
+}
+
+func newChange(c container.FilesystemChange) Change {
+	kind := ChangeModified
+	switch c.Kind {
+	case container.ChangeAdd:
+		kind = ChangeAdded
+	case container.ChangeDelete:
+		kind = ChangeDeleted
+	}
+	return Change{Kind: kind, Path: c.Path}
+}
+
+type diffConfig struct {
+	prefixes []string
+	kinds    map[ChangeKind]bool
+}
+
+func (d diffConfig) match(c Change) bool {
+	if len(d.kinds) > 0 && !d.kinds[c.Kind] {
+		return false
+	}
+	if len(d.prefixes) == 0 {
+		return true
+	}
+	for _, p := range d.prefixes {
+		p = strings.TrimSuffix(p, "/")
+		if c.Path == p || strings.HasPrefix(c.Path, p+"/") || p == "" {
+			return true
+		}
+	}
+	return false
+}
+
+type DiffOption func(*diffConfig)
+
+// DiffWithPathPrefix only keep changes under any of the prefixes, i.e. /etc matches /etc and /etc/hosts, not /etcd
+func DiffWithPathPrefix(prefixes ...string) DiffOption {
+	return func(o *diffConfig) {
+		o.prefixes = append(o.prefixes, prefixes...)
+	}
+}
+
+// DiffWithKinds only keep changes of the kinds
+func DiffWithKinds(kinds ...ChangeKind) DiffOption {
+	return func(o *diffConfig) {
+		if o.kinds == nil {
+			o.kinds = make(map[ChangeKind]bool)
+		}
+		for _, k := range kinds {
+			o.kinds[k] = true
+		}
+	}
+}
+
+// Diff return changes of container filesystem relative to its image, container can name or id
+func (c ContainerClient) Diff(container string, options ...DiffOption) ([]Change, error) {
+	return c.DiffContext(context.Background(), container, options...)
+}
+
+func (c ContainerClient) DiffContext(ctx context.Context, container string, options ...DiffOption) ([]Change, error) {
+	o := diffConfig{}
+	for _, option := range options {
+		option(&o)
+	}
+	r, err := c.c.ContainerDiff(ctx, container)
+	if err != nil {
+		return nil, err
+	}
+	var changes []Change
+	for _, i := range r {
+		if change := newChange(i); o.match(change) {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
+
+// DiffArchive return contents of added and modified files as a tar stream, names are paths without leading "/"
+// directories and deleted paths are not included, a file removed before being archived is skipped
+func (c ContainerClient) DiffArchive(container string, options ...DiffOption) (io.ReadCloser, error) {
+	return c.DiffArchiveContext(context.Background(), container, options...)
+}
+
+func (c ContainerClient) DiffArchiveContext(ctx context.Context, container string, options ...DiffOption) (io.ReadCloser, error) {
+	changes, err := c.DiffContext(ctx, container, options...)
+	if err != nil {
+		return nil, err
+	}
+	return tarstream.FromFunc(func(tw *tar.Writer) error {
+		for _, change := range changes {
+			if change.Kind == ChangeDeleted {
+				continue
+			}
+			if err := c.archiveChange(ctx, tw, container, change.Path); err != nil {
+				return err
+			}
+		}
+		return nil
+	}), nil
+}
+
+func (c ContainerClient) archiveChange(ctx context.Context, tw *tar.Writer, container, path string) error {
+	r, _, err := c.c.CopyFromContainer(ctx, container, path)
+	if client.IsErrNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	tr := tar.NewReader(r)
+	h, err := tr.Next()
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if h.Typeflag == tar.TypeDir {
+		return nil
+	}
+	h.Name = strings.TrimPrefix(path, "/")
+	if err = tw.WriteHeader(h); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, tr)
+	return err
+}
@@ -0,0 +1,144 @@
+package container
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/riete/docker/common/tarstream"
+)
+
+// maxSymlinkHops limit symlink resolving in ReadFile, same as linux MAXSYMLINKS
+const maxSymlinkHops = 40
+
+type FileInfo struct {
+	Name       string
+	Mode       fs.FileMode
+	Size       int64
+	ModTime    time.Time
+	LinkTarget string
+	Uid        int
+	Gid        int
+}
+
+// ReadFile return content of file path in container, symlinks are followed
+func (c ContainerClient) ReadFile(container, path string) ([]byte, error) {
+	return c.ReadFileContext(context.Background(), container, path)
+}
+
+func (c ContainerClient) ReadFileContext(ctx context.Context, container, filePath string) ([]byte, error) {
+	for i := 0; i < maxSymlinkHops; i++ {
+		r, _, err := c.c.CopyFromContainer(ctx, container, filePath)
+		if err != nil {
+			return nil, err
+		}
+		h, b, err := readFirstEntry(r)
+		r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read %s error: %w", filePath, err)
+		}
+		switch h.Typeflag {
+		case tar.TypeReg:
+			return b, nil
+		case tar.TypeSymlink:
+			if path.IsAbs(h.Linkname) {
+				filePath = h.Linkname
+			} else {
+				filePath = path.Join(path.Dir(filePath), h.Linkname)
+			}
+		case tar.TypeDir:
+			return nil, fmt.Errorf("%s is a directory", filePath)
+		default:
+			return nil, fmt.Errorf("%s is not a regular file", filePath)
+		}
+	}
+	return nil, fmt.Errorf("read %s error: too many levels of symbolic links", filePath)
+}
+
+// readFirstEntry return the first tar header and its content
+func readFirstEntry(r io.Reader) (*tar.Header, []byte, error) {
+	tr := tar.NewReader(r)
+	h, err := tr.Next()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, errors.New("empty archive")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	b, err := io.ReadAll(tr)
+	return h, b, err
+}
+
+// WriteFile write data to file path in container, parent directory is created if not exists
+// the file is owned by uid:gid, they are numeric ids in container
+func (c ContainerClient) WriteFile(container, path string, data []byte, mode fs.FileMode, uid, gid int) error {
+	return c.WriteFileContext(context.Background(), container, path, data, mode, uid, gid)
+}
+
+func (c ContainerClient) WriteFileContext(ctx context.Context, container, filePath string, data []byte, mode fs.FileMode, uid, gid int) error {
+	h := &tar.Header{
+		Name:    path.Base(filePath),
+		Mode:    int64(mode.Perm()),
+		Uid:     uid,
+		Gid:     gid,
+		ModTime: time.Now(),
+	}
+	r := tarstream.FromHeader(h, data)
+	defer r.Close()
+	// without copy uid/gid the daemon keeps Uid and Gid of the tar header, otherwise files are owned by Config.User
+	return c.CopyTarToContext(ctx, r, container, path.Dir(filePath))
+}
+
+// ListDir return direct entries of directory path in container
+// note the daemon archives the whole directory tree, avoid listing huge directories
+func (c ContainerClient) ListDir(container, path string) ([]FileInfo, error) {
+	return c.ListDirContext(context.Background(), container, path)
+}
+
+func (c ContainerClient) ListDirContext(ctx context.Context, container, dirPath string) ([]FileInfo, error) {
+	stat, _, err := c.PathStatContext(ctx, container, dirPath)
+	if err != nil {
+		return nil, err
+	}
+	if !stat.Mode.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dirPath)
+	}
+
+	r, _, err := c.c.CopyFromContainer(ctx, container, strings.TrimSuffix(dirPath, "/")+"/.")
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var entries []FileInfo
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		// entry names are prefixed with the directory name, direct children have exactly one more element
+		parts := strings.Split(strings.TrimSuffix(h.Name, "/"), "/")
+		if len(parts) != 2 {
+			continue
+		}
+		entries = append(entries, FileInfo{
+			Name:       parts[1],
+			Mode:       h.FileInfo().Mode(),
+			Size:       h.Size,
+			ModTime:    h.ModTime,
+			LinkTarget: h.Linkname,
+			Uid:        h.Uid,
+			Gid:        h.Gid,
+		})
+	}
+}
@@ -2,14 +2,22 @@ package container
 
 import (
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/riete/docker/common/filter"
 
 	"github.com/docker/go-connections/nat"
+	"github.com/docker/go-units"
 
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
 )
 
 type ListOption func(options *types.ContainerListOptions)
@@ -43,6 +51,13 @@ func ListWithFilters(f map[string]string) ListOption {
 	}
 }
 
+// ListWithFilterArgs use filters built by filter.New, a key can have multiple values, keys are validated by List
+func ListWithFilterArgs(f filters.Args) ListOption {
+	return func(o *types.ContainerListOptions) {
+		o.Filters = f
+	}
+}
+
 type TimeoutOption func(options *container.StopOptions)
 
 func StopWithTimeout(t *int) TimeoutOption {
@@ -319,6 +334,32 @@ func CreateWithStopTimeout(n int) CreateOption {
 	}
 }
 
+// CreateWithStopSignal signal sent by Stop, i.e. SIGQUIT, default is SIGTERM or STOPSIGNAL of image
+func CreateWithStopSignal(signal string) CreateOption {
+	return func(o *ContainerCreateConfig) {
+		o.Config.StopSignal = signal
+	}
+}
+
+// CreateWithOpenStdin keep stdin open even if not attached, like docker run -i
+func CreateWithOpenStdin() CreateOption {
+	return func(o *ContainerCreateConfig) {
+		o.Config.OpenStdin = true
+	}
+}
+
+// CreateWithExposedPorts use nat.ParsePortSpecs to get nat.PortSet
+func CreateWithExposedPorts(ports nat.PortSet) CreateOption {
+	return func(o *ContainerCreateConfig) {
+		if o.Config.ExposedPorts == nil {
+			o.Config.ExposedPorts = make(nat.PortSet)
+		}
+		for p := range ports {
+			o.Config.ExposedPorts[p] = struct{}{}
+		}
+	}
+}
+
 // CreateWithBindsMap binds key is host-src or volume, value is container-dest[:options]
 // host-src, container-dest must be an absolute path
 func CreateWithBindsMap(binds map[string]string) CreateOption {
@@ -395,3 +436,464 @@ func CreateWithPidMode(mode string) CreateOption {
 		o.HostConfig.PidMode = container.PidMode(mode)
 	}
 }
+
+type UpdateOption func(config *container.UpdateConfig)
+
+func UpdateWithCpuNums(n float64) UpdateOption {
+	return func(o *container.UpdateConfig) {
+		o.NanoCPUs = int64(n * 1e9)
+	}
+}
+
+// UpdateWithCpuQuota limit cpu cfs quota in microseconds per period, i.e. period 100000 and quota 50000 is half cpu
+func UpdateWithCpuQuota(period, quota int64) UpdateOption {
+	return func(o *container.UpdateConfig) {
+		o.CPUPeriod = period
+		o.CPUQuota = quota
+	}
+}
+
+// UpdateWithCpuShares relative cpu weight against other containers, default is 1024
+func UpdateWithCpuShares(shares int64) UpdateOption {
+	return func(o *container.UpdateConfig) {
+		o.CPUShares = shares
+	}
+}
+
+// UpdateWithCpusetCpus cpus allowed to execute, i.e. "0-3" or "0,1"
+func UpdateWithCpusetCpus(cpus string) UpdateOption {
+	return func(o *container.UpdateConfig) {
+		o.CpusetCpus = cpus
+	}
+}
+
+// UpdateWithCpusetMems memory nodes allowed to execute, i.e. "0-3" or "0,1", only effective on NUMA systems
+func UpdateWithCpusetMems(mems string) UpdateOption {
+	return func(o *container.UpdateConfig) {
+		o.CpusetMems = mems
+	}
+}
+
+func UpdateWithMemoryLimit(n int64) UpdateOption {
+	return func(o *container.UpdateConfig) {
+		o.Memory = n
+	}
+}
+
+// UpdateWithMemorySwap total memory limit(memory + swap), -1 is unlimited swap
+func UpdateWithMemorySwap(n int64) UpdateOption {
+	return func(o *container.UpdateConfig) {
+		o.MemorySwap = n
+	}
+}
+
+// UpdateWithMemoryReservation soft memory limit, must be smaller than memory limit
+func UpdateWithMemoryReservation(n int64) UpdateOption {
+	return func(o *container.UpdateConfig) {
+		o.MemoryReservation = n
+	}
+}
+
+// UpdateWithPidsLimit 0 or -1 is unlimited
+func UpdateWithPidsLimit(n int64) UpdateOption {
+	return func(o *container.UpdateConfig) {
+		o.PidsLimit = &n
+	}
+}
+
+// UpdateWithBlkioWeight relative block io weight, between 10 and 1000, 0 to disable
+func UpdateWithBlkioWeight(weight uint16) UpdateOption {
+	return func(o *container.UpdateConfig) {
+		o.BlkioWeight = weight
+	}
+}
+
+// UpdateWithRestartPolicy can use restart.AlwaysPolicy and etc., to get policy
+func UpdateWithRestartPolicy(policy container.RestartPolicy) UpdateOption {
+	return func(o *container.UpdateConfig) {
+		o.RestartPolicy = policy
+	}
+}
+
+type MountOption func(*mount.Mount)
+
+func MountWithReadOnly() MountOption {
+	return func(o *mount.Mount) {
+		o.ReadOnly = true
+	}
+}
+
+// MountWithNoCopy do not populate a new volume with data in the image, volume mount only
+func MountWithNoCopy() MountOption {
+	return func(o *mount.Mount) {
+		if o.VolumeOptions == nil {
+			o.VolumeOptions = &mount.VolumeOptions{}
+		}
+		o.VolumeOptions.NoCopy = true
+	}
+}
+
+// MountWithVolumeDriver driver used to create the volume if not exists, volume mount only
+func MountWithVolumeDriver(name string, options map[string]string) MountOption {
+	return func(o *mount.Mount) {
+		if o.VolumeOptions == nil {
+			o.VolumeOptions = &mount.VolumeOptions{}
+		}
+		o.VolumeOptions.DriverConfig = &mount.Driver{Name: name, Options: options}
+	}
+}
+
+// MountWithVolumeLabels labels set on the volume if it is created, volume mount only
+func MountWithVolumeLabels(labels map[string]string) MountOption {
+	return func(o *mount.Mount) {
+		if o.VolumeOptions == nil {
+			o.VolumeOptions = &mount.VolumeOptions{}
+		}
+		o.VolumeOptions.Labels = labels
+	}
+}
+
+// MountWithPropagation bind mount only, can use mount.PropagationRPrivate and etc.
+func MountWithPropagation(propagation mount.Propagation) MountOption {
+	return func(o *mount.Mount) {
+		if o.BindOptions == nil {
+			o.BindOptions = &mount.BindOptions{}
+		}
+		o.BindOptions.Propagation = propagation
+	}
+}
+
+// MountWithTmpfsSize size of tmpfs mount in bytes, default is unlimited, tmpfs mount only
+func MountWithTmpfsSize(n int64) MountOption {
+	return func(o *mount.Mount) {
+		if o.TmpfsOptions == nil {
+			o.TmpfsOptions = &mount.TmpfsOptions{}
+		}
+		o.TmpfsOptions.SizeBytes = n
+	}
+}
+
+// MountWithTmpfsMode file mode of tmpfs mount, default is 1777, tmpfs mount only
+func MountWithTmpfsMode(mode os.FileMode) MountOption {
+	return func(o *mount.Mount) {
+		if o.TmpfsOptions == nil {
+			o.TmpfsOptions = &mount.TmpfsOptions{}
+		}
+		o.TmpfsOptions.Mode = mode
+	}
+}
+
+func newMount(t mount.Type, source, target string, options ...MountOption) mount.Mount {
+	m := mount.Mount{Type: t, Source: source, Target: target}
+	for _, option := range options {
+		option(&m)
+	}
+	return m
+}
+
+// CreateWithVolumeMount mount named volume source at target, source "" is an anonymous volume
+func CreateWithVolumeMount(source, target string, options ...MountOption) CreateOption {
+	return func(o *ContainerCreateConfig) {
+		o.HostConfig.Mounts = append(o.HostConfig.Mounts, newMount(mount.TypeVolume, source, target, options...))
+	}
+}
+
+// CreateWithBindMount mount host path source at target, source and target must be absolute paths
+func CreateWithBindMount(source, target string, options ...MountOption) CreateOption {
+	return func(o *ContainerCreateConfig) {
+		o.HostConfig.Mounts = append(o.HostConfig.Mounts, newMount(mount.TypeBind, source, target, options...))
+	}
+}
+
+func CreateWithTmpfsMount(target string, options ...MountOption) CreateOption {
+	return func(o *ContainerCreateConfig) {
+		o.HostConfig.Mounts = append(o.HostConfig.Mounts, newMount(mount.TypeTmpfs, "", target, options...))
+	}
+}
+
+func CreateWithMounts(mounts ...mount.Mount) CreateOption {
+	return func(o *ContainerCreateConfig) {
+		o.HostConfig.Mounts = append(o.HostConfig.Mounts, mounts...)
+	}
+}
+
+func healthcheck(o *ContainerCreateConfig) *container.HealthConfig {
+	if o.Config.Healthcheck == nil {
+		o.Config.Healthcheck = &container.HealthConfig{}
+	}
+	return o.Config.Healthcheck
+}
+
+// CreateWithHealthCmd cmd is run with the default shell in container, exit code 0 is healthy
+func CreateWithHealthCmd(cmd string) CreateOption {
+	return func(o *ContainerCreateConfig) {
+		healthcheck(o).Test = []string{"CMD-SHELL", cmd}
+	}
+}
+
+// CreateWithHealthExec cmd argv is executed directly without shell, exit code 0 is healthy
+func CreateWithHealthExec(cmd []string) CreateOption {
+	return func(o *ContainerCreateConfig) {
+		healthcheck(o).Test = append([]string{"CMD"}, cmd...)
+	}
+}
+
+// CreateWithNoHealthcheck disable healthcheck defined in image
+func CreateWithNoHealthcheck() CreateOption {
+	return func(o *ContainerCreateConfig) {
+		healthcheck(o).Test = []string{"NONE"}
+	}
+}
+
+// CreateWithHealthInterval time between running the check, default is 30s
+func CreateWithHealthInterval(d time.Duration) CreateOption {
+	return func(o *ContainerCreateConfig) {
+		healthcheck(o).Interval = d
+	}
+}
+
+// CreateWithHealthTimeout maximum time to allow one check to run, default is 30s
+func CreateWithHealthTimeout(d time.Duration) CreateOption {
+	return func(o *ContainerCreateConfig) {
+		healthcheck(o).Timeout = d
+	}
+}
+
+// CreateWithHealthStartPeriod failures during start period are not counted, default is 0s
+func CreateWithHealthStartPeriod(d time.Duration) CreateOption {
+	return func(o *ContainerCreateConfig) {
+		healthcheck(o).StartPeriod = d
+	}
+}
+
+// CreateWithHealthRetries consecutive failures needed to report unhealthy, default is 3
+func CreateWithHealthRetries(n int) CreateOption {
+	return func(o *ContainerCreateConfig) {
+		healthcheck(o).Retries = n
+	}
+}
+
+// CreateWithCapAdd add linux capabilities, i.e. NET_ADMIN, SYS_PTRACE
+func CreateWithCapAdd(caps ...string) CreateOption {
+	return func(o *ContainerCreateConfig) {
+		o.HostConfig.CapAdd = append(o.HostConfig.CapAdd, caps...)
+	}
+}
+
+// CreateWithCapDrop drop linux capabilities, "ALL" drops all capabilities
+func CreateWithCapDrop(caps ...string) CreateOption {
+	return func(o *ContainerCreateConfig) {
+		o.HostConfig.CapDrop = append(o.HostConfig.CapDrop, caps...)
+	}
+}
+
+// CreateWithReadonlyRootfs mount container root filesystem as read only, use tmpfs or volume mounts for writable paths
+func CreateWithReadonlyRootfs() CreateOption {
+	return func(o *ContainerCreateConfig) {
+		o.HostConfig.ReadonlyRootfs = true
+	}
+}
+
+// CreateWithSecurityOpt raw security options, i.e. "label=disable"
+func CreateWithSecurityOpt(opts ...string) CreateOption {
+	return func(o *ContainerCreateConfig) {
+		o.HostConfig.SecurityOpt = append(o.HostConfig.SecurityOpt, opts...)
+	}
+}
+
+// CreateWithNoNewPrivileges prevent processes gaining new privileges through setuid or setgid binaries
+func CreateWithNoNewPrivileges() CreateOption {
+	return CreateWithSecurityOpt("no-new-privileges:true")
+}
+
+// CreateWithSeccompProfile profile is the json content of a seccomp profile, or "unconfined" to disable seccomp
+func CreateWithSeccompProfile(profile string) CreateOption {
+	return CreateWithSecurityOpt("seccomp=" + profile)
+}
+
+// CreateWithApparmorProfile profile is the name of a loaded apparmor profile, or "unconfined" to disable apparmor
+func CreateWithApparmorProfile(profile string) CreateOption {
+	return CreateWithSecurityOpt("apparmor=" + profile)
+}
+
+// CreateWithUsernsMode user namespace mode, "host" disables user namespace remapping
+func CreateWithUsernsMode(mode string) CreateOption {
+	return func(o *ContainerCreateConfig) {
+		o.HostConfig.UsernsMode = container.UsernsMode(mode)
+	}
+}
+
+// CreateWithUlimit name is ulimit name without RLIMIT_ prefix, i.e. nofile, nproc
+func CreateWithUlimit(name string, soft, hard int64) CreateOption {
+	return func(o *ContainerCreateConfig) {
+		o.HostConfig.Ulimits = append(o.HostConfig.Ulimits, &units.Ulimit{Name: name, Soft: soft, Hard: hard})
+	}
+}
+
+// CreateWithMaskedPaths paths in container are masked and can not be read
+func CreateWithMaskedPaths(paths ...string) CreateOption {
+	return func(o *ContainerCreateConfig) {
+		o.HostConfig.MaskedPaths = append(o.HostConfig.MaskedPaths, paths...)
+	}
+}
+
+// CreateWithReadonlyPaths paths in container are read only
+func CreateWithReadonlyPaths(paths ...string) CreateOption {
+	return func(o *ContainerCreateConfig) {
+		o.HostConfig.ReadonlyPaths = append(o.HostConfig.ReadonlyPaths, paths...)
+	}
+}
+
+// CreateWithRestrictedProfile safe defaults for untrusted workloads
+// drop all capabilities, read only root filesystem, no new privileges and not privileged
+// apply CreateWithCapAdd after it to grant the few capabilities a workload needs
+func CreateWithRestrictedProfile() CreateOption {
+	return func(o *ContainerCreateConfig) {
+		o.HostConfig.Privileged = false
+		CreateWithCapDrop("ALL")(o)
+		CreateWithReadonlyRootfs()(o)
+		CreateWithNoNewPrivileges()(o)
+	}
+}
+
+type EndpointOption func(*network.EndpointSettings)
+
+// EndpointWithAliases dns names of the container on the network
+func EndpointWithAliases(aliases ...string) EndpointOption {
+	return func(o *network.EndpointSettings) {
+		o.Aliases = append(o.Aliases, aliases...)
+	}
+}
+
+// EndpointWithIPv4 static ipv4 address, network must be created with a subnet containing it
+func EndpointWithIPv4(ip string) EndpointOption {
+	return func(o *network.EndpointSettings) {
+		if o.IPAMConfig == nil {
+			o.IPAMConfig = &network.EndpointIPAMConfig{}
+		}
+		o.IPAMConfig.IPv4Address = ip
+	}
+}
+
+// EndpointWithIPv6 static ipv6 address, network must be created with ipv6 enabled and a subnet containing it
+func EndpointWithIPv6(ip string) EndpointOption {
+	return func(o *network.EndpointSettings) {
+		if o.IPAMConfig == nil {
+			o.IPAMConfig = &network.EndpointIPAMConfig{}
+		}
+		o.IPAMConfig.IPv6Address = ip
+	}
+}
+
+// EndpointWithLinks link format is container[:alias]
+func EndpointWithLinks(links ...string) EndpointOption {
+	return func(o *network.EndpointSettings) {
+		o.Links = append(o.Links, links...)
+	}
+}
+
+// CreateWithNetwork attach container to network name, can be used multiple times for multiple networks
+// the first attached network becomes the network mode if CreateWithNetworkMode is not used
+func CreateWithNetwork(name string, options ...EndpointOption) CreateOption {
+	return func(o *ContainerCreateConfig) {
+		e := &network.EndpointSettings{}
+		for _, option := range options {
+			option(e)
+		}
+		if o.NetworkConfig.EndpointsConfig == nil {
+			o.NetworkConfig.EndpointsConfig = make(map[string]*network.EndpointSettings)
+		}
+		o.NetworkConfig.EndpointsConfig[name] = e
+		if o.HostConfig.NetworkMode == "" {
+			o.HostConfig.NetworkMode = container.NetworkMode(name)
+		}
+	}
+}
+
+func CreateWithDns(servers ...string) CreateOption {
+	return func(o *ContainerCreateConfig) {
+		o.HostConfig.DNS = append(o.HostConfig.DNS, servers...)
+	}
+}
+
+func CreateWithDnsSearch(domains ...string) CreateOption {
+	return func(o *ContainerCreateConfig) {
+		o.HostConfig.DNSSearch = append(o.HostConfig.DNSSearch, domains...)
+	}
+}
+
+// CreateWithDnsOptions resolv.conf options, i.e. "ndots:2"
+func CreateWithDnsOptions(options ...string) CreateOption {
+	return func(o *ContainerCreateConfig) {
+		o.HostConfig.DNSOptions = append(o.HostConfig.DNSOptions, options...)
+	}
+}
+
+// CreateWithExtraHosts host format is hostname:ip, ip can be "host-gateway" for the host ip
+func CreateWithExtraHosts(hosts ...string) CreateOption {
+	return func(o *ContainerCreateConfig) {
+		o.HostConfig.ExtraHosts = append(o.HostConfig.ExtraHosts, hosts...)
+	}
+}
+
+type LogOption func(map[string]string)
+
+// LogWithMaxSize maximum size of log file before rotation, i.e. "10m", json-file and local driver only
+func LogWithMaxSize(size string) LogOption {
+	return func(m map[string]string) {
+		m["max-size"] = size
+	}
+}
+
+// LogWithMaxFile maximum number of rotated log files, json-file and local driver only
+func LogWithMaxFile(n int) LogOption {
+	return func(m map[string]string) {
+		m["max-file"] = strconv.Itoa(n)
+	}
+}
+
+// LogWithCompress compress rotated log files, json-file and local driver only
+func LogWithCompress(compress bool) LogOption {
+	return func(m map[string]string) {
+		m["compress"] = strconv.FormatBool(compress)
+	}
+}
+
+// LogWithTag tag of log messages, support go template, i.e. "{{.Name}}/{{.ID}}"
+func LogWithTag(tag string) LogOption {
+	return func(m map[string]string) {
+		m["tag"] = tag
+	}
+}
+
+// LogWithLabels container label keys included in log messages
+func LogWithLabels(keys ...string) LogOption {
+	return func(m map[string]string) {
+		m["labels"] = strings.Join(keys, ",")
+	}
+}
+
+// LogWithEnv container env keys included in log messages
+func LogWithEnv(keys ...string) LogOption {
+	return func(m map[string]string) {
+		m["env"] = strings.Join(keys, ",")
+	}
+}
+
+// LogWithOpt any driver specific option, i.e. "syslog-address", "tcp://192.168.0.42:123"
+func LogWithOpt(key, value string) LogOption {
+	return func(m map[string]string) {
+		m[key] = value
+	}
+}
+
+// CreateWithLogDriver options of built-in drivers are validated before creating
+func CreateWithLogDriver(driver LogDriver, options ...LogOption) CreateOption {
+	return func(o *ContainerCreateConfig) {
+		config := container.LogConfig{Type: string(driver), Config: make(map[string]string)}
+		for _, option := range options {
+			option(config.Config)
+		}
+		o.HostConfig.LogConfig = config
+	}
+}
@@ -0,0 +1,157 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/go-connections/nat"
+)
+
+const defaultHostPortTimeout = 10 * time.Second
+
+type PortMapping struct {
+	// ContainerPort is port/protocol, i.e. 5432/tcp
+	ContainerPort string
+	// HostIP and HostPort are empty if the port is exposed but not published
+	HostIP   string
+	HostPort string
+}
+
+func containerPort(port string) nat.Port {
+	if !strings.Contains(port, "/") {
+		port += "/tcp"
+	}
+	return nat.Port(port)
+}
+
+// HostPort return host ip and port that port of container is published on, port is "5432/tcp" or "5432" for tcp
+// wait up to 10 seconds until the port is bound, a wildcard host ip is returned as loopback so that it is dialable
+func (c ContainerClient) HostPort(container, port string) (string, string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultHostPortTimeout)
+	defer cancel()
+	return c.HostPortContext(ctx, container, port)
+}
+
+// HostPortContext wait until the port is bound, ctx is done or container exits
+// a port neither in port bindings nor exposed with publish all fails immediately
+func (c ContainerClient) HostPortContext(ctx context.Context, container, port string) (string, string, error) {
+	var ip, hostPort string
+	err := poll(ctx, func() (bool, error) {
+		var done bool
+		var err error
+		ip, hostPort, done, err = c.hostPort(ctx, container, port)
+		return done || err == nil, err
+	})
+	return ip, hostPort, err
+}
+
+// hostPort look up the published port once, done is true if container exited or the port is not published at all
+func (c ContainerClient) hostPort(ctx context.Context, container, port string) (string, string, bool, error) {
+	i, _, err := c.InspectContext(ctx, container)
+	if err != nil {
+		return "", "", false, err
+	}
+	if err = notRunning(i); err != nil {
+		return "", "", true, err
+	}
+	if i.NetworkSettings == nil {
+		return "", "", false, errors.New("network settings is not available")
+	}
+	p := containerPort(port)
+	bindings := i.NetworkSettings.Ports[p]
+	if len(bindings) == 0 {
+		return "", "", !publishable(i, p), fmt.Errorf("port %s is not published", port)
+	}
+	host := bindings[0].HostIP
+	switch host {
+	case "", "0.0.0.0":
+		host = "127.0.0.1"
+	case "::":
+		host = "::1"
+	}
+	return host, bindings[0].HostPort, false, nil
+}
+
+// publishable report whether port is in port bindings or exposed with publish all, otherwise it will never be bound
+func publishable(i types.ContainerJSON, port nat.Port) bool {
+	if i.HostConfig != nil {
+		if _, ok := i.HostConfig.PortBindings[port]; ok {
+			return true
+		}
+		if i.HostConfig.PublishAllPorts && i.Config != nil {
+			_, ok := i.Config.ExposedPorts[port]
+			return ok
+		}
+	}
+	return false
+}
+
+// PortMappings return all exposed and published ports of container, sorted by container port
+// a port published on multiple host addresses has one mapping for each
+func (c ContainerClient) PortMappings(container string) ([]PortMapping, error) {
+	return c.PortMappingsContext(context.Background(), container)
+}
+
+func (c ContainerClient) PortMappingsContext(ctx context.Context, container string) ([]PortMapping, error) {
+	i, _, err := c.InspectContext(ctx, container)
+	if err != nil {
+		return nil, err
+	}
+	if i.NetworkSettings == nil {
+		return nil, nil
+	}
+	ports := make([]nat.Port, 0, len(i.NetworkSettings.Ports))
+	for p := range i.NetworkSettings.Ports {
+		ports = append(ports, p)
+	}
+	sort.Slice(ports, func(a, b int) bool {
+		if ports[a].Int() != ports[b].Int() {
+			return ports[a].Int() < ports[b].Int()
+		}
+		return ports[a].Proto() < ports[b].Proto()
+	})
+	var mappings []PortMapping
+	for _, p := range ports {
+		bindings := i.NetworkSettings.Ports[p]
+		if len(bindings) == 0 {
+			mappings = append(mappings, PortMapping{ContainerPort: string(p)})
+		}
+		for _, b := range bindings {
+			mappings = append(mappings, PortMapping{ContainerPort: string(p), HostIP: b.HostIP, HostPort: b.HostPort})
+		}
+	}
+	return mappings, nil
+}
+
+// IPAddress return ipv4 address of container on network, network can be empty for the network of network mode
+// the address is only available while container is running
+func (c ContainerClient) IPAddress(container, network string) (string, error) {
+	return c.IPAddressContext(context.Background(), container, network)
+}
+
+func (c ContainerClient) IPAddressContext(ctx context.Context, container, network string) (string, error) {
+	i, _, err := c.InspectContext(ctx, container)
+	if err != nil {
+		return "", err
+	}
+	if network == "" {
+		network = i.HostConfig.NetworkMode.NetworkName()
+		// default network mode is bridge on linux
+		if network == "default" || network == "" {
+			network = "bridge"
+		}
+	}
+	if i.NetworkSettings == nil || i.NetworkSettings.Networks[network] == nil {
+		return "", fmt.Errorf("container %s is not connected to network %s", container, network)
+	}
+	ip := i.NetworkSettings.Networks[network].IPAddress
+	if ip == "" {
+		return "", fmt.Errorf("container %s has no address on network %s", container, network)
+	}
+	return ip, nil
+}
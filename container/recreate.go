@@ -0,0 +1,140 @@
+package container
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
+)
+
+const recreateOldSuffix = "-old-"
+
+type recreateConfig struct {
+	pull         bool
+	registryAuth string
+	stop         TimeoutOption
+	strategies   []WaitStrategy
+	keepOld      bool
+}
+
+type RecreateOption func(*recreateConfig)
+
+// RecreateWithoutPull use the local image, by default the image is always pulled
+func RecreateWithoutPull() RecreateOption {
+	return func(o *recreateConfig) {
+		o.pull = false
+	}
+}
+
+// RecreateWithRegistryAuth base64 encoded auth used to pull the image
+func RecreateWithRegistryAuth(auth string) RecreateOption {
+	return func(o *recreateConfig) {
+		o.registryAuth = auth
+	}
+}
+
+// RecreateWithStopTimeout how to stop the old container, default is StopWithDefaultTimeout
+func RecreateWithStopTimeout(option TimeoutOption) RecreateOption {
+	return func(o *recreateConfig) {
+		o.stop = option
+	}
+}
+
+// RecreateWithWaitStrategy wait until the new container is ready after start, roll back if it is not
+func RecreateWithWaitStrategy(strategies ...WaitStrategy) RecreateOption {
+	return func(o *recreateConfig) {
+		o.strategies = append(o.strategies, strategies...)
+	}
+}
+
+// RecreateWithKeepOld keep the old container stopped as "<name>-old-<random>" instead of removing it
+func RecreateWithKeepOld() RecreateOption {
+	return func(o *recreateConfig) {
+		o.keepOld = true
+	}
+}
+
+// Recreate replace container with a new one from image, its config, host config and networks are preserved
+// settings inherited from the old image are dropped so that defaults of the new image apply
+// anonymous volumes are mounted into the new container by name, data in them is kept
+// the new container is created as "<name>-new-<random>", the names are swapped after the old one is stopped
+// if the new container fails to start or be ready, it is removed and the old one is restored
+// errors are *ReplaceError
+func (c ContainerClient) Recreate(container, image string, options ...RecreateOption) (container.CreateResponse, error) {
+	return c.RecreateContext(context.Background(), container, image, options...)
+}
+
+func (c ContainerClient) RecreateContext(ctx context.Context, target, image string, options ...RecreateOption) (container.CreateResponse, error) {
+	o := &recreateConfig{pull: true, stop: StopWithDefaultTimeout()}
+	for _, option := range options {
+		option(o)
+	}
+	i, _, err := c.InspectContext(ctx, target)
+	if err != nil {
+		return container.CreateResponse{}, &ReplaceError{Step: ReplaceStepInspect, Container: target, Err: err}
+	}
+	name := strings.TrimPrefix(i.Name, "/")
+	img := &container.Config{}
+	if ii, _, err := c.c.ImageInspectWithRaw(ctx, i.Image); err == nil && ii.Config != nil {
+		img = ii.Config
+	} else if err != nil && !client.IsErrNotFound(err) {
+		return container.CreateResponse{}, &ReplaceError{Step: ReplaceStepInspect, Container: name, Err: err}
+	}
+	if o.pull {
+		if err = c.pull(ctx, image, o.registryAuth); err != nil {
+			return container.CreateResponse{}, &ReplaceError{Step: ReplaceStepPull, Container: name, Err: err}
+		}
+	}
+
+	cfg := createConfigFromInspect(i, img)
+	cfg.Config.Image = image
+	if err = cfg.Validate(); err != nil {
+		return container.CreateResponse{}, &ReplaceError{Step: ReplaceStepValidate, Container: name, Err: err}
+	}
+	staging := uniqueName(name, replaceStagingSuffix)
+	// unique, so that a container kept by an earlier RecreateWithKeepOld does not block renaming
+	oldName := uniqueName(name, recreateOldSuffix)
+	r, err := c.create(ctx, staging, cfg)
+	if err != nil {
+		return r, &ReplaceError{Step: ReplaceStepCreate, Container: staging, Err: err}
+	}
+
+	running := i.State != nil && i.State.Running
+	if err = c.StopContext(ctx, i.ID, o.stop); err != nil {
+		return r, &ReplaceError{Step: ReplaceStepStop, Container: name, Err: c.rollback(i.ID, r.ID, name, false, false, err)}
+	}
+	if err = c.RenameContext(ctx, i.ID, oldName); err != nil {
+		return r, &ReplaceError{Step: ReplaceStepRename, Container: name, Err: c.rollback(i.ID, r.ID, name, false, running, err)}
+	}
+	if err = c.RenameContext(ctx, r.ID, name); err != nil {
+		return r, &ReplaceError{Step: ReplaceStepRename, Container: staging, Err: c.rollback(i.ID, r.ID, name, true, running, err)}
+	}
+	if err = c.StartContext(ctx, r.ID); err != nil {
+		return r, &ReplaceError{Step: ReplaceStepStart, Container: name, Err: c.rollback(i.ID, r.ID, name, true, running, err)}
+	}
+	if len(o.strategies) > 0 {
+		if err = c.WaitReadyContext(ctx, r.ID, o.strategies...); err != nil {
+			return r, &ReplaceError{Step: ReplaceStepReady, Container: name, Err: c.rollback(i.ID, r.ID, name, true, running, err)}
+		}
+	}
+	if !o.keepOld {
+		if err = c.RemoveContext(ctx, i.ID, RemoveWithForce()); err != nil {
+			return r, &ReplaceError{Step: ReplaceStepRemove, Container: oldName, Err: err}
+		}
+	}
+	return r, nil
+}
+
+// pull image and wait until done, an error reported in the progress stream is returned
+func (c ContainerClient) pull(ctx context.Context, image, registryAuth string) error {
+	r, err := c.c.ImagePull(ctx, image, types.ImagePullOptions{RegistryAuth: registryAuth})
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return jsonmessage.DisplayJSONMessagesStream(r, io.Discard, 0, false, nil)
+}
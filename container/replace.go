@@ -0,0 +1,104 @@
+package container
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+)
+
+const replaceStagingSuffix = "-new-"
+
+// uniqueName return name with suffix and a random part appended, a leftover of an earlier replace never conflicts with it
+func uniqueName(name, suffix string) string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return name + suffix + hex.EncodeToString(b)
+}
+
+type ReplaceStep string
+
+const (
+	ReplaceStepValidate ReplaceStep = "validate"
+	ReplaceStepInspect  ReplaceStep = "inspect"
+	ReplaceStepPull     ReplaceStep = "pull"
+	ReplaceStepCreate   ReplaceStep = "create"
+	ReplaceStepStop     ReplaceStep = "stop"
+	ReplaceStepRename   ReplaceStep = "rename"
+	ReplaceStepStart    ReplaceStep = "start"
+	ReplaceStepReady    ReplaceStep = "ready"
+	ReplaceStepRemove   ReplaceStep = "remove"
+)
+
+// ReplaceError replacing a container failed at Step, Container is the name of the container the step acted on
+// errors of rolling back, if any, are joined into Err
+type ReplaceError struct {
+	Step      ReplaceStep
+	Container string
+	Err       error
+}
+
+func (e *ReplaceError) Error() string {
+	return fmt.Sprintf("replace failed at %s %s: %v", e.Step, e.Container, e.Err)
+}
+
+func (e *ReplaceError) Unwrap() error {
+	return e.Err
+}
+
+// replace create container name from validated config without losing the existing one on error
+// the new container is created as "<name>-new-<random>", the old one is stopped and removed only after that succeeds
+// if renaming fails at last, the new container is left as "<name>-new-<random>", see ReplaceError.Container
+func (c ContainerClient) replace(ctx context.Context, name string, o *ContainerCreateConfig) (container.CreateResponse, error) {
+	old, _, err := c.InspectContext(ctx, name)
+	if client.IsErrNotFound(err) {
+		r, err := c.create(ctx, name, o)
+		if err != nil {
+			return r, &ReplaceError{Step: ReplaceStepCreate, Container: name, Err: err}
+		}
+		return r, nil
+	}
+	if err != nil {
+		return container.CreateResponse{}, &ReplaceError{Step: ReplaceStepInspect, Container: name, Err: err}
+	}
+	staging := uniqueName(name, replaceStagingSuffix)
+	r, err := c.create(ctx, staging, o)
+	if err != nil {
+		return r, &ReplaceError{Step: ReplaceStepCreate, Container: staging, Err: err}
+	}
+	if err = c.StopContext(ctx, old.ID, StopWithDefaultTimeout()); err != nil {
+		return r, &ReplaceError{Step: ReplaceStepStop, Container: name, Err: c.rollback(old.ID, r.ID, name, false, false, err)}
+	}
+	if err = c.RemoveContext(ctx, old.ID, RemoveWithForce()); err != nil {
+		running := old.State != nil && old.State.Running
+		return r, &ReplaceError{Step: ReplaceStepRemove, Container: name, Err: c.rollback(old.ID, r.ID, name, false, running, err)}
+	}
+	if err = c.RenameContext(ctx, r.ID, name); err != nil {
+		return r, &ReplaceError{Step: ReplaceStepRename, Container: staging, Err: err}
+	}
+	return r, nil
+}
+
+// rollback remove the new container and restore the old one, set renamed if the old one has been renamed
+// ctx may be done already, rolling back must not depend on it
+func (c ContainerClient) rollback(oldId, newId, name string, renamed, running bool, cause error) error {
+	errs := []error{cause}
+	if err := c.Remove(newId, RemoveWithForce()); err != nil {
+		errs = append(errs, fmt.Errorf("rollback remove new container error: %w", err))
+	}
+	if renamed {
+		if err := c.Rename(oldId, name); err != nil {
+			errs = append(errs, fmt.Errorf("rollback rename %s error: %w", name, err))
+		}
+	}
+	if running {
+		if err := c.Start(oldId); err != nil {
+			errs = append(errs, fmt.Errorf("rollback start %s error: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
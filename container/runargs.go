@@ -0,0 +1,453 @@
+package container
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-connections/nat"
+	"github.com/docker/go-units"
+
+	"github.com/riete/docker/common/restart"
+)
+
+// UnsupportedFlagError flags of docker run which can not be translated into CreateOption
+type UnsupportedFlagError struct {
+	Flags []string
+}
+
+func (e *UnsupportedFlagError) Error() string {
+	return "unsupported docker run flags: " + strings.Join(e.Flags, ", ")
+}
+
+// runArgs collect repeatable flags, they are turned into options after all args are parsed
+type runArgs struct {
+	name        string
+	env         []string
+	labels      map[string]string
+	binds       []string
+	volumes     []string
+	ports       []string
+	expose      []string
+	networks    []string
+	logDriver   string
+	logOpts     []LogOption
+	options     []CreateOption
+	unsupported []string
+}
+
+type runFlag struct {
+	hasValue bool
+	apply    func(a *runArgs, value string) error
+}
+
+func valueFlag(apply func(a *runArgs, value string) error) runFlag {
+	return runFlag{hasValue: true, apply: apply}
+}
+
+func boolFlag(option CreateOption) runFlag {
+	return runFlag{apply: func(a *runArgs, _ string) error {
+		if option != nil {
+			a.options = append(a.options, option)
+		}
+		return nil
+	}}
+}
+
+func optionFlag(f func(string) (CreateOption, error)) runFlag {
+	return valueFlag(func(a *runArgs, v string) error {
+		option, err := f(v)
+		if err != nil {
+			return err
+		}
+		a.options = append(a.options, option)
+		return nil
+	})
+}
+
+func durationFlag(f func(time.Duration) CreateOption) runFlag {
+	return optionFlag(func(v string) (CreateOption, error) {
+		d, err := time.ParseDuration(v)
+		return f(d), err
+	})
+}
+
+func stringFlag(f func(string) CreateOption) runFlag {
+	return optionFlag(func(v string) (CreateOption, error) {
+		return f(v), nil
+	})
+}
+
+func variadicFlag(f func(...string) CreateOption) runFlag {
+	return optionFlag(func(v string) (CreateOption, error) {
+		return f(v), nil
+	})
+}
+
+var runShortFlags = map[byte]string{
+	'd': "detach",
+	'e': "env",
+	'h': "hostname",
+	'i': "interactive",
+	'l': "label",
+	'm': "memory",
+	'p': "publish",
+	'P': "publish-all",
+	't': "tty",
+	'u': "user",
+	'v': "volume",
+	'w': "workdir",
+}
+
+var runFlags = map[string]runFlag{
+	// detach only affects docker cli, container config is the same
+	"detach":      boolFlag(nil),
+	"interactive": boolFlag(func(o *ContainerCreateConfig) { CreateWithOpenStdin()(o); CreateWithAttachStdin()(o) }),
+	"tty":         boolFlag(CreateWithTty()),
+	"rm":          boolFlag(CreateWithAutoRemove()),
+	"privileged":  boolFlag(CreateWithPrivileged()),
+	"publish-all": boolFlag(CreateWithPublishAllPorts()),
+	"read-only":   boolFlag(CreateWithReadonlyRootfs()),
+
+	"no-healthcheck": boolFlag(CreateWithNoHealthcheck()),
+	"name": valueFlag(func(a *runArgs, v string) error {
+		a.name = v
+		return nil
+	}),
+	"env": valueFlag(func(a *runArgs, v string) error {
+		a.env = append(a.env, expandEnv(v)...)
+		return nil
+	}),
+	"env-file": valueFlag(func(a *runArgs, v string) error {
+		env, err := readEnvFile(v)
+		a.env = append(a.env, env...)
+		return err
+	}),
+	"label": valueFlag(func(a *runArgs, v string) error {
+		k, value, _ := strings.Cut(v, "=")
+		a.labels[k] = value
+		return nil
+	}),
+	"volume": valueFlag(func(a *runArgs, v string) error {
+		if strings.Contains(v, ":") {
+			a.binds = append(a.binds, v)
+		} else {
+			a.volumes = append(a.volumes, v)
+		}
+		return nil
+	}),
+	"publish": valueFlag(func(a *runArgs, v string) error {
+		a.ports = append(a.ports, v)
+		return nil
+	}),
+	"expose": valueFlag(func(a *runArgs, v string) error {
+		a.expose = append(a.expose, v)
+		return nil
+	}),
+	"network": valueFlag(func(a *runArgs, v string) error {
+		a.networks = append(a.networks, v)
+		return nil
+	}),
+	"log-driver": valueFlag(func(a *runArgs, v string) error {
+		a.logDriver = v
+		return nil
+	}),
+	"log-opt": valueFlag(func(a *runArgs, v string) error {
+		k, value, found := strings.Cut(v, "=")
+		if !found {
+			return fmt.Errorf("invalid log-opt %q, format is key=value", v)
+		}
+		a.logOpts = append(a.logOpts, LogWithOpt(k, value))
+		return nil
+	}),
+	"restart": optionFlag(parseRestartPolicy),
+	"cpus": optionFlag(func(v string) (CreateOption, error) {
+		n, err := strconv.ParseFloat(v, 64)
+		return CreateWithCpuNums(n), err
+	}),
+	"memory": optionFlag(func(v string) (CreateOption, error) {
+		n, err := units.RAMInBytes(v)
+		return CreateWithMemoryLimit(n), err
+	}),
+	"entrypoint": optionFlag(func(v string) (CreateOption, error) {
+		// empty entrypoint reset the entrypoint of image, an empty list would fall back to it like unset
+		if v == "" {
+			return CreateWithEntrypoint([]string{""}), nil
+		}
+		return CreateWithEntrypoint([]string{v}), nil
+	}),
+	"stop-timeout": optionFlag(func(v string) (CreateOption, error) {
+		n, err := strconv.Atoi(v)
+		return CreateWithStopTimeout(n), err
+	}),
+	"stop-signal": optionFlag(func(v string) (CreateOption, error) {
+		return CreateWithStopSignal(v), nil
+	}),
+	"health-retries": optionFlag(func(v string) (CreateOption, error) {
+		n, err := strconv.Atoi(v)
+		return CreateWithHealthRetries(n), err
+	}),
+	"health-cmd":          stringFlag(CreateWithHealthCmd),
+	"health-interval":     durationFlag(CreateWithHealthInterval),
+	"health-timeout":      durationFlag(CreateWithHealthTimeout),
+	"health-start-period": durationFlag(CreateWithHealthStartPeriod),
+	"workdir":             stringFlag(CreateWithWorkingDir),
+	"user":                stringFlag(CreateWithUser),
+	"hostname":            stringFlag(CreateWithHostname),
+	"pid":                 stringFlag(CreateWithPidMode),
+	"userns":              stringFlag(CreateWithUsernsMode),
+	"cap-add":             variadicFlag(CreateWithCapAdd),
+	"cap-drop":            variadicFlag(CreateWithCapDrop),
+	"security-opt":        variadicFlag(CreateWithSecurityOpt),
+	"dns":                 variadicFlag(CreateWithDns),
+	"dns-search":          variadicFlag(CreateWithDnsSearch),
+	"dns-option":          variadicFlag(CreateWithDnsOptions),
+	"add-host":            variadicFlag(CreateWithExtraHosts),
+}
+
+var runFlagAliases = map[string]string{
+	"net":     "network",
+	"dns-opt": "dns-option",
+}
+
+func lookupRunFlag(name string) (runFlag, bool) {
+	if alias, ok := runFlagAliases[name]; ok {
+		name = alias
+	}
+	f, ok := runFlags[name]
+	return f, ok
+}
+
+// expandEnv "KEY" without value take the value from current environment like docker cli, it is dropped if not set
+func expandEnv(v string) []string {
+	if strings.Contains(v, "=") {
+		return []string{v}
+	}
+	if value, ok := os.LookupEnv(v); ok {
+		return []string{v + "=" + value}
+	}
+	return nil
+}
+
+func readEnvFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var env []string
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimLeft(s.Text(), " \t")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		env = append(env, expandEnv(line)...)
+	}
+	return env, s.Err()
+}
+
+func parseRestartPolicy(v string) (CreateOption, error) {
+	name, retry, _ := strings.Cut(v, ":")
+	switch name {
+	case "no":
+		return CreateWithRestartPolicy(container.RestartPolicy{Name: "no"}), nil
+	case "always":
+		return CreateWithRestartPolicy(restart.AlwaysPolicy()), nil
+	case "unless-stopped":
+		return CreateWithRestartPolicy(restart.UnlessStoppedPolicy()), nil
+	case "on-failure":
+		n := 0
+		if retry != "" {
+			var err error
+			if n, err = strconv.Atoi(retry); err != nil {
+				return nil, fmt.Errorf("invalid restart retry count %q", retry)
+			}
+		}
+		return CreateWithRestartPolicy(restart.OnFailurePolicy(n)), nil
+	}
+	return nil, fmt.Errorf("invalid restart policy %q", v)
+}
+
+// trimRunCommand drop leading "docker run" or "docker container run"
+func trimRunCommand(args []string) []string {
+	if len(args) > 0 && args[0] == "docker" {
+		args = args[1:]
+	}
+	if len(args) > 0 && args[0] == "container" {
+		args = args[1:]
+	}
+	if len(args) > 0 && args[0] == "run" {
+		args = args[1:]
+	}
+	return args
+}
+
+// ParseRunArgs parse docker run argument list into image, container name and create options
+// args may start with "docker run", i.e. strings.Fields("docker run -d --name web -p 80:80 nginx")
+// all unsupported flags are reported together as *UnsupportedFlagError
+func ParseRunArgs(args []string) (string, string, []CreateOption, error) {
+	args = trimRunCommand(args)
+	a := &runArgs{labels: make(map[string]string)}
+	var positional []string
+	var errs []error
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		// flags after image belong to command
+		if arg == "--" {
+			positional = args[i+1:]
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			positional = args[i:]
+			break
+		}
+
+		// next consume the following arg as flag value
+		next := func(flag string) (string, bool) {
+			if i+1 >= len(args) {
+				errs = append(errs, fmt.Errorf("flag %s needs a value", flag))
+				return "", false
+			}
+			i++
+			return args[i], true
+		}
+
+		if strings.HasPrefix(arg, "--") {
+			name, value, hasValue := strings.Cut(arg[2:], "=")
+			f, ok := lookupRunFlag(name)
+			if !ok {
+				a.unsupported = append(a.unsupported, "--"+name)
+				continue
+			}
+			if f.hasValue && !hasValue {
+				if value, ok = next("--" + name); !ok {
+					continue
+				}
+			}
+			if !f.hasValue && hasValue {
+				if b, err := strconv.ParseBool(value); err != nil {
+					errs = append(errs, fmt.Errorf("invalid boolean value %q of flag --%s", value, name))
+					continue
+				} else if !b {
+					continue
+				}
+			}
+			if err := f.apply(a, value); err != nil {
+				errs = append(errs, fmt.Errorf("flag --%s: %w", name, err))
+			}
+			continue
+		}
+
+		// short flags can be combined, i.e. -it, and value can be attached, i.e. -p80:80 or -e=K=V
+		for j := 1; j < len(arg); j++ {
+			name, ok := runShortFlags[arg[j]]
+			if !ok {
+				a.unsupported = append(a.unsupported, "-"+string(arg[j]))
+				continue
+			}
+			f, _ := lookupRunFlag(name)
+			if !f.hasValue {
+				_ = f.apply(a, "")
+				continue
+			}
+			value := strings.TrimPrefix(arg[j+1:], "=")
+			if value == "" {
+				if value, ok = next("-" + string(arg[j])); !ok {
+					break
+				}
+			}
+			if err := f.apply(a, value); err != nil {
+				errs = append(errs, fmt.Errorf("flag -%s: %w", string(arg[j]), err))
+			}
+			break
+		}
+	}
+
+	if len(a.unsupported) > 0 {
+		errs = append(errs, &UnsupportedFlagError{Flags: a.unsupported})
+	}
+	if len(positional) == 0 {
+		errs = append(errs, errors.New("image is required"))
+	}
+	options, err := a.createOptions()
+	if err != nil {
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return "", "", nil, errors.Join(errs...)
+	}
+	if len(positional) > 1 {
+		options = append(options, CreateWithCmd(positional[1:]))
+	}
+	return positional[0], a.name, options, nil
+}
+
+// exposePorts add port or port range of --expose, i.e. 9000-9001/udp
+func exposePorts(exposed nat.PortSet, v string) error {
+	if strings.Contains(v, ":") {
+		return fmt.Errorf("invalid port format for --expose: %s", v)
+	}
+	proto, port := nat.SplitProtoPort(v)
+	start, end, err := nat.ParsePortRange(port)
+	if err != nil {
+		return fmt.Errorf("invalid range format for --expose: %s, error: %w", v, err)
+	}
+	for n := start; n <= end; n++ {
+		p, err := nat.NewPort(proto, strconv.FormatUint(n, 10))
+		if err != nil {
+			return err
+		}
+		exposed[p] = struct{}{}
+	}
+	return nil
+}
+
+func (a *runArgs) createOptions() ([]CreateOption, error) {
+	options := a.options
+	if len(a.env) > 0 {
+		options = append(options, CreateWithEnvArray(a.env))
+	}
+	if len(a.labels) > 0 {
+		options = append(options, CreateWithLabels(a.labels))
+	}
+	if len(a.binds) > 0 {
+		options = append(options, CreateWithBindsArray(a.binds))
+	}
+	for _, v := range a.volumes {
+		options = append(options, CreateWithVolumeMount("", v))
+	}
+	if len(a.ports) > 0 || len(a.expose) > 0 {
+		exposed, bindings, err := nat.ParsePortSpecs(a.ports)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range a.expose {
+			if err = exposePorts(exposed, e); err != nil {
+				return nil, err
+			}
+		}
+		options = append(options, CreateWithExposedPorts(exposed))
+		if len(bindings) > 0 {
+			options = append(options, CreateWithPortBindings(bindings))
+		}
+	}
+	for _, n := range a.networks {
+		switch mode := container.NetworkMode(n); {
+		case mode.IsHost(), mode.IsNone(), mode.IsContainer(), mode.IsDefault(), mode.IsBridge():
+			options = append(options, CreateWithNetworkMode(n))
+		default:
+			options = append(options, CreateWithNetwork(n))
+		}
+	}
+	if a.logDriver != "" || len(a.logOpts) > 0 {
+		options = append(options, CreateWithLogDriver(LogDriver(a.logDriver), a.logOpts...))
+	}
+	return options, nil
+}
@@ -0,0 +1,192 @@
+package container
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/go-connections/nat"
+)
+
+func parseRunArgs(t *testing.T, command string) (string, string, *ContainerCreateConfig, error) {
+	t.Helper()
+	image, name, options, err := ParseRunArgs(strings.Fields(command))
+	if err != nil {
+		return image, name, nil, err
+	}
+	o := NewContainerCreateConfig()
+	for _, option := range options {
+		option(o)
+	}
+	return image, name, o, o.Validate()
+}
+
+func TestParseRunArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		image   string
+		cname   string
+		check   func(t *testing.T, o *ContainerCreateConfig)
+	}{
+		{
+			name:    "image and command",
+			command: "docker run -d --name web nginx nginx -g daemon",
+			image:   "nginx",
+			cname:   "web",
+			check: func(t *testing.T, o *ContainerCreateConfig) {
+				if want := []string{"nginx", "-g", "daemon"}; !reflect.DeepEqual([]string(o.Config.Cmd), want) {
+					t.Errorf("cmd = %q, want %q", o.Config.Cmd, want)
+				}
+			},
+		},
+		{
+			name:    "combined short flags and attached values",
+			command: "run -it -p80:80 -e=A=1 -l app=web alpine",
+			image:   "alpine",
+			check: func(t *testing.T, o *ContainerCreateConfig) {
+				if !o.Config.Tty || !o.Config.OpenStdin {
+					t.Errorf("tty = %t, open stdin = %t, want both", o.Config.Tty, o.Config.OpenStdin)
+				}
+				if want := []string{"A=1"}; !reflect.DeepEqual(o.Config.Env, want) {
+					t.Errorf("env = %q, want %q", o.Config.Env, want)
+				}
+				if o.Config.Labels["app"] != "web" {
+					t.Errorf("labels = %v, want app=web", o.Config.Labels)
+				}
+				if b := o.HostConfig.PortBindings["80/tcp"]; len(b) != 1 || b[0].HostPort != "80" {
+					t.Errorf("port bindings = %v, want 80/tcp on 80", o.HostConfig.PortBindings)
+				}
+			},
+		},
+		{
+			name:    "empty entrypoint resets image entrypoint",
+			command: "run --entrypoint= alpine",
+			image:   "alpine",
+			check: func(t *testing.T, o *ContainerCreateConfig) {
+				if want := []string{""}; !reflect.DeepEqual([]string(o.Config.Entrypoint), want) {
+					t.Errorf("entrypoint = %q, want %q", o.Config.Entrypoint, want)
+				}
+			},
+		},
+		{
+			name:    "entrypoint",
+			command: "run --entrypoint /bin/sh alpine -c true",
+			image:   "alpine",
+			check: func(t *testing.T, o *ContainerCreateConfig) {
+				if want := []string{"/bin/sh"}; !reflect.DeepEqual([]string(o.Config.Entrypoint), want) {
+					t.Errorf("entrypoint = %q, want %q", o.Config.Entrypoint, want)
+				}
+			},
+		},
+		{
+			name:    "expose port range",
+			command: "run --expose 9000-9001 --expose 53/udp alpine",
+			image:   "alpine",
+			check: func(t *testing.T, o *ContainerCreateConfig) {
+				want := nat.PortSet{"9000/tcp": {}, "9001/tcp": {}, "53/udp": {}}
+				if !reflect.DeepEqual(o.Config.ExposedPorts, want) {
+					t.Errorf("exposed ports = %v, want %v", o.Config.ExposedPorts, want)
+				}
+			},
+		},
+		{
+			name:    "stop signal and restart policy",
+			command: "run --stop-signal SIGQUIT --restart on-failure:3 alpine",
+			image:   "alpine",
+			check: func(t *testing.T, o *ContainerCreateConfig) {
+				if o.Config.StopSignal != "SIGQUIT" {
+					t.Errorf("stop signal = %q, want SIGQUIT", o.Config.StopSignal)
+				}
+				if p := o.HostConfig.RestartPolicy; p.Name != "on-failure" || p.MaximumRetryCount != 3 {
+					t.Errorf("restart policy = %+v, want on-failure:3", p)
+				}
+			},
+		},
+		{
+			name:    "network mode and user network",
+			command: "run --network bridge --net app alpine",
+			image:   "alpine",
+			check: func(t *testing.T, o *ContainerCreateConfig) {
+				if o.HostConfig.NetworkMode != "bridge" {
+					t.Errorf("network mode = %q, want bridge", o.HostConfig.NetworkMode)
+				}
+				if _, ok := o.NetworkConfig.EndpointsConfig["app"]; !ok {
+					t.Errorf("endpoints = %v, want app", o.NetworkConfig.EndpointsConfig)
+				}
+			},
+		},
+		{
+			name:    "generic log options",
+			command: "run --log-driver json-file --log-opt mode=non-blocking --log-opt max-buffer-size=4m alpine",
+			image:   "alpine",
+			check: func(t *testing.T, o *ContainerCreateConfig) {
+				want := container.LogConfig{Type: "json-file", Config: map[string]string{"mode": "non-blocking", "max-buffer-size": "4m"}}
+				if !reflect.DeepEqual(o.HostConfig.LogConfig, want) {
+					t.Errorf("log config = %+v, want %+v", o.HostConfig.LogConfig, want)
+				}
+			},
+		},
+		{
+			name:    "double dash ends flags",
+			command: "run -d -- alpine ls -l",
+			image:   "alpine",
+			check: func(t *testing.T, o *ContainerCreateConfig) {
+				if want := []string{"ls", "-l"}; !reflect.DeepEqual([]string(o.Config.Cmd), want) {
+					t.Errorf("cmd = %q, want %q", o.Config.Cmd, want)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			image, name, o, err := parseRunArgs(t, tt.command)
+			if err != nil {
+				t.Fatalf("ParseRunArgs(%q) error: %v", tt.command, err)
+			}
+			if image != tt.image || name != tt.cname {
+				t.Errorf("image, name = %q, %q, want %q, %q", image, name, tt.image, tt.cname)
+			}
+			tt.check(t, o)
+		})
+	}
+}
+
+func TestParseRunArgsError(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    string
+	}{
+		{"missing image", "run -d", "image is required"},
+		{"flag needs value", "run --name", "flag --name needs a value"},
+		{"malformed expose", "run --expose abc alpine", "invalid range format for --expose"},
+		{"expose with host port", "run --expose 80:80 alpine", "invalid port format for --expose"},
+		{"reversed expose range", "run --expose 9001-9000 alpine", "invalid range format for --expose"},
+		{"invalid restart policy", "run --restart sometimes alpine", "invalid restart policy"},
+		{"invalid log mode", "run --log-driver json-file --log-opt mode=async alpine", "invalid log option mode"},
+		{"buffer size without non-blocking", "run --log-driver local --log-opt max-buffer-size=4m alpine", "only supported with mode non-blocking"},
+		{"unknown log option", "run --log-driver local --log-opt tag=x alpine", "unknown log option tag"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, _, err := parseRunArgs(t, tt.command)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseRunArgs(%q) error = %v, want %q", tt.command, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRunArgsUnsupported(t *testing.T) {
+	_, _, _, err := ParseRunArgs(strings.Fields("run --gpus=all -x alpine"))
+	var e *UnsupportedFlagError
+	if !errors.As(err, &e) {
+		t.Fatalf("error = %v, want *UnsupportedFlagError", err)
+	}
+	if want := []string{"--gpus", "-x"}; !reflect.DeepEqual(e.Flags, want) {
+		t.Errorf("unsupported flags = %q, want %q", e.Flags, want)
+	}
+}
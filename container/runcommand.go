@@ -0,0 +1,456 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/network"
+	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/nat"
+)
+
+// RunCommand docker run command line and the equivalent CreateOption go code to recreate a container
+type RunCommand struct {
+	Image string
+	Name  string
+	// Args starts with "docker", "run"
+	Args []string
+	// Command is Args joined and quoted for a posix shell
+	Command string
+	// Code is a []container.CreateOption literal, pass it to ContainerClient.Create with Image and Name
+	Code string
+}
+
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_./:=,@%+-]+$`)
+
+func shellQuote(s string) string {
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// runCommandBuilder every container setting appends docker run flags and the matching go code
+type runCommandBuilder struct {
+	args []string
+	code []string
+}
+
+func (b *runCommandBuilder) add(code string, args ...string) {
+	b.args = append(b.args, args...)
+	if code != "" {
+		b.code = append(b.code, "\tcontainer."+code+",")
+	}
+}
+
+// humanBytes use the largest exact unit so that the value is readable and lossless
+func humanBytes(n int64) string {
+	for _, u := range []struct {
+		suffix string
+		size   int64
+	}{{"g", 1 << 30}, {"m", 1 << 20}, {"k", 1 << 10}} {
+		if n%u.size == 0 {
+			return fmt.Sprintf("%d%s", n/u.size, u.suffix)
+		}
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// GenerateRunCommand return docker run command and go code which recreate container from its inspect
+// values equal to image defaults are omitted, settings docker run can not express are only in Code
+// anonymous volumes are mounted by name, so that the new container keeps their data
+func (c ContainerClient) GenerateRunCommand(container string) (RunCommand, error) {
+	return c.GenerateRunCommandContext(context.Background(), container)
+}
+
+func (c ContainerClient) GenerateRunCommandContext(ctx context.Context, target string) (RunCommand, error) {
+	i, _, err := c.InspectContext(ctx, target)
+	if err != nil {
+		return RunCommand{}, err
+	}
+	img := &container.Config{}
+	if ii, _, err := c.c.ImageInspectWithRaw(ctx, i.Image); err == nil && ii.Config != nil {
+		img = ii.Config
+	} else if err != nil && !client.IsErrNotFound(err) {
+		return RunCommand{}, err
+	}
+
+	r := RunCommand{Image: i.Config.Image, Name: strings.TrimPrefix(i.Name, "/")}
+	cfg := createConfigFromInspect(i, img)
+	b := &runCommandBuilder{args: []string{"docker", "run", "-d", "--name", r.Name}}
+	generateConfig(b, cfg.Config)
+	generateHostConfig(b, cfg.HostConfig)
+	generateMounts(b, cfg.HostConfig.Mounts)
+	generatePorts(b, cfg.Config, cfg.HostConfig)
+	generateNetworks(b, cfg.HostConfig.NetworkMode, cfg.NetworkConfig)
+	generateHealthcheck(b, cfg.Config.Healthcheck)
+
+	b.args = append(b.args, r.Image)
+	entrypoint, cmd := cfg.Config.Entrypoint, cfg.Config.Cmd
+	if entrypoint != nil {
+		// docker run --entrypoint accept one executable only, the rest of entrypoint is prepended to command
+		// image command is cleared when entrypoint is overwritten, so command is always kept
+		if len(entrypoint) > 1 {
+			b.args = append(b.args, entrypoint[1:]...)
+		}
+		b.args = append(b.args, cmd...)
+	} else if cmd != nil {
+		b.add(fmt.Sprintf("CreateWithCmd(%#v)", []string(cmd)), cmd...)
+	}
+
+	r.Args = b.args
+	quoted := make([]string, len(b.args))
+	for n, a := range b.args {
+		quoted[n] = shellQuote(a)
+	}
+	r.Command = strings.Join(quoted, " ")
+	r.Code = "[]container.CreateOption{\n" + strings.Join(b.code, "\n") + "\n}"
+	return r, nil
+}
+
+func generateConfig(b *runCommandBuilder, cfg *container.Config) {
+	if cfg.Hostname != "" {
+		b.add(fmt.Sprintf("CreateWithHostname(%q)", cfg.Hostname), "--hostname", cfg.Hostname)
+	}
+	if cfg.User != "" {
+		b.add(fmt.Sprintf("CreateWithUser(%q)", cfg.User), "--user", cfg.User)
+	}
+	if cfg.WorkingDir != "" {
+		b.add(fmt.Sprintf("CreateWithWorkingDir(%q)", cfg.WorkingDir), "--workdir", cfg.WorkingDir)
+	}
+	if cfg.Entrypoint != nil {
+		b.add(fmt.Sprintf("CreateWithEntrypoint(%#v)", append([]string{}, cfg.Entrypoint...)), "--entrypoint", cfg.Entrypoint[0])
+		b.add(fmt.Sprintf("CreateWithCmd(%#v)", append([]string{}, cfg.Cmd...)))
+	}
+	if cfg.Tty {
+		b.add("CreateWithTty()", "--tty")
+	}
+	if cfg.OpenStdin {
+		b.add("CreateWithOpenStdin()", "--interactive")
+	}
+	if cfg.StopSignal != "" {
+		b.add(fmt.Sprintf("CreateWithStopSignal(%q)", cfg.StopSignal), "--stop-signal", cfg.StopSignal)
+	}
+	if cfg.StopTimeout != nil {
+		b.add(fmt.Sprintf("CreateWithStopTimeout(%d)", *cfg.StopTimeout), "--stop-timeout", strconv.Itoa(*cfg.StopTimeout))
+	}
+
+	if len(cfg.Env) > 0 {
+		for _, e := range cfg.Env {
+			b.add("", "--env", e)
+		}
+		b.add(fmt.Sprintf("CreateWithEnvArray(%#v)", cfg.Env))
+	}
+	if len(cfg.Labels) > 0 {
+		for _, k := range sortedKeys(cfg.Labels) {
+			b.add("", "--label", k+"="+cfg.Labels[k])
+		}
+		b.add(fmt.Sprintf("CreateWithLabels(%#v)", cfg.Labels))
+	}
+	for _, v := range sortedKeys(cfg.Volumes) {
+		b.add(fmt.Sprintf("CreateWithVolumeMount(\"\", %q)", v), "--volume", v)
+	}
+}
+
+func generateHostConfig(b *runCommandBuilder, hc *container.HostConfig) {
+	switch p := hc.RestartPolicy; p.Name {
+	case "", "no":
+	case "on-failure":
+		b.add(fmt.Sprintf("CreateWithRestartPolicy(restart.OnFailurePolicy(%d))", p.MaximumRetryCount), "--restart", fmt.Sprintf("on-failure:%d", p.MaximumRetryCount))
+	case "always":
+		b.add("CreateWithRestartPolicy(restart.AlwaysPolicy())", "--restart", p.Name)
+	case "unless-stopped":
+		b.add("CreateWithRestartPolicy(restart.UnlessStoppedPolicy())", "--restart", p.Name)
+	}
+	if hc.AutoRemove {
+		b.add("CreateWithAutoRemove()", "--rm")
+	}
+	if hc.Privileged {
+		b.add("CreateWithPrivileged()", "--privileged")
+	}
+	if hc.ReadonlyRootfs {
+		b.add("CreateWithReadonlyRootfs()", "--read-only")
+	}
+	if hc.NanoCPUs > 0 {
+		n := float64(hc.NanoCPUs) / 1e9
+		b.add(fmt.Sprintf("CreateWithCpuNums(%g)", n), "--cpus", strconv.FormatFloat(n, 'f', -1, 64))
+	}
+	if hc.Memory > 0 {
+		b.add(fmt.Sprintf("CreateWithMemoryLimit(%d)", hc.Memory), "--memory", humanBytes(hc.Memory))
+	}
+	if hc.PidMode != "" {
+		b.add(fmt.Sprintf("CreateWithPidMode(%q)", hc.PidMode), "--pid", string(hc.PidMode))
+	}
+	if hc.UsernsMode != "" {
+		b.add(fmt.Sprintf("CreateWithUsernsMode(%q)", hc.UsernsMode), "--userns", string(hc.UsernsMode))
+	}
+
+	repeated := []struct {
+		option string
+		flag   string
+		values []string
+	}{
+		{"CreateWithCapAdd", "--cap-add", hc.CapAdd},
+		{"CreateWithCapDrop", "--cap-drop", hc.CapDrop},
+		{"CreateWithSecurityOpt", "--security-opt", hc.SecurityOpt},
+		{"CreateWithDns", "--dns", hc.DNS},
+		{"CreateWithDnsSearch", "--dns-search", hc.DNSSearch},
+		{"CreateWithDnsOptions", "--dns-option", hc.DNSOptions},
+		{"CreateWithExtraHosts", "--add-host", hc.ExtraHosts},
+	}
+	for _, r := range repeated {
+		if len(r.values) == 0 {
+			continue
+		}
+		for _, v := range r.values {
+			b.add("", r.flag, v)
+		}
+		b.add(fmt.Sprintf("%s(%s)", r.option, quoteAll(r.values)))
+	}
+
+	for _, u := range hc.Ulimits {
+		b.add(fmt.Sprintf("CreateWithUlimit(%q, %d, %d)", u.Name, u.Soft, u.Hard), "--ulimit", fmt.Sprintf("%s=%d:%d", u.Name, u.Soft, u.Hard))
+	}
+	// masked and readonly paths have no docker run flag, they are set by default profile unless privileged
+	if len(hc.Binds) > 0 {
+		for _, bind := range hc.Binds {
+			b.add("", "--volume", bind)
+		}
+		b.add(fmt.Sprintf("CreateWithBindsArray(%#v)", hc.Binds))
+	}
+
+	// json-file is the default log driver unless changed in daemon.json
+	if l := hc.LogConfig; (l.Type != "" && l.Type != string(LogDriverJsonFile)) || len(l.Config) > 0 {
+		var code []string
+		if l.Type != "" {
+			b.add("", "--log-driver", l.Type)
+		}
+		for _, k := range sortedKeys(l.Config) {
+			b.add("", "--log-opt", k+"="+l.Config[k])
+			code = append(code, fmt.Sprintf("LogWithOpt(%q, %q)", k, l.Config[k]))
+		}
+		b.add(fmt.Sprintf("CreateWithLogDriver(%q%s)", l.Type, joinCode(code)))
+	}
+}
+
+// joinCode format option arguments which follow a leading argument
+func joinCode(code []string) string {
+	if len(code) == 0 {
+		return ""
+	}
+	return ", container." + strings.Join(code, ", container.")
+}
+
+func generateMounts(b *runCommandBuilder, mounts []mount.Mount) {
+	for _, m := range mounts {
+		var code []string
+		arg := []string{"type=" + string(m.Type)}
+		if m.Source != "" {
+			arg = append(arg, "source="+m.Source)
+		}
+		arg = append(arg, "target="+m.Target)
+		if m.ReadOnly {
+			arg = append(arg, "readonly")
+			code = append(code, "MountWithReadOnly()")
+		}
+		if o := m.VolumeOptions; o != nil {
+			if o.NoCopy {
+				arg = append(arg, "volume-nocopy")
+				code = append(code, "MountWithNoCopy()")
+			}
+			if o.DriverConfig != nil && o.DriverConfig.Name != "" {
+				arg = append(arg, "volume-driver="+o.DriverConfig.Name)
+				for _, k := range sortedKeys(o.DriverConfig.Options) {
+					arg = append(arg, "volume-opt="+k+"="+o.DriverConfig.Options[k])
+				}
+				code = append(code, fmt.Sprintf("MountWithVolumeDriver(%q, %#v)", o.DriverConfig.Name, o.DriverConfig.Options))
+			}
+		}
+		if o := m.BindOptions; o != nil && o.Propagation != "" {
+			arg = append(arg, "bind-propagation="+string(o.Propagation))
+			code = append(code, fmt.Sprintf("MountWithPropagation(%q)", o.Propagation))
+		}
+		if o := m.TmpfsOptions; o != nil {
+			if o.SizeBytes > 0 {
+				arg = append(arg, "tmpfs-size="+strconv.FormatInt(o.SizeBytes, 10))
+				code = append(code, fmt.Sprintf("MountWithTmpfsSize(%d)", o.SizeBytes))
+			}
+			if o.Mode != 0 {
+				arg = append(arg, fmt.Sprintf("tmpfs-mode=%o", o.Mode))
+				code = append(code, fmt.Sprintf("MountWithTmpfsMode(%#o)", o.Mode))
+			}
+		}
+
+		switch m.Type {
+		case mount.TypeVolume:
+			b.add(fmt.Sprintf("CreateWithVolumeMount(%q, %q%s)", m.Source, m.Target, joinCode(code)), "--mount", strings.Join(arg, ","))
+		case mount.TypeBind:
+			b.add(fmt.Sprintf("CreateWithBindMount(%q, %q%s)", m.Source, m.Target, joinCode(code)), "--mount", strings.Join(arg, ","))
+		case mount.TypeTmpfs:
+			b.add(fmt.Sprintf("CreateWithTmpfsMount(%q%s)", m.Target, joinCode(code)), "--mount", strings.Join(arg, ","))
+		default:
+			// other mount types have no type specific options, nested option pointers must not be printed with %#v
+			b.add(fmt.Sprintf("CreateWithMounts(mount.Mount{Type: %q, Source: %q, Target: %q, ReadOnly: %t})", m.Type, m.Source, m.Target, m.ReadOnly), "--mount", strings.Join(arg, ","))
+		}
+	}
+}
+
+func generatePorts(b *runCommandBuilder, cfg *container.Config, hc *container.HostConfig) {
+	if hc.PublishAllPorts {
+		b.add("CreateWithPublishAllPorts()", "--publish-all")
+	}
+	exposed := make(nat.PortSet)
+	for p := range cfg.ExposedPorts {
+		exposed[p] = struct{}{}
+	}
+	ports := make([]string, 0, len(hc.PortBindings))
+	for p := range hc.PortBindings {
+		ports = append(ports, string(p))
+	}
+	sort.Strings(ports)
+	for _, p := range ports {
+		port := nat.Port(p)
+		delete(exposed, port)
+		containerPort := port.Port()
+		if port.Proto() != "tcp" {
+			containerPort += "/" + port.Proto()
+		}
+		for _, binding := range hc.PortBindings[port] {
+			switch {
+			case binding.HostIP != "":
+				b.add("", "--publish", binding.HostIP+":"+binding.HostPort+":"+containerPort)
+			case binding.HostPort != "":
+				b.add("", "--publish", binding.HostPort+":"+containerPort)
+			default:
+				b.add("", "--publish", containerPort)
+			}
+		}
+	}
+	if len(ports) > 0 {
+		b.add(fmt.Sprintf("CreateWithPortBindings(%#v)", hc.PortBindings))
+	}
+	if len(exposed) > 0 {
+		expose := make([]string, 0, len(exposed))
+		for p := range exposed {
+			expose = append(expose, string(p))
+		}
+		sort.Strings(expose)
+		for _, p := range expose {
+			b.add("", "--expose", p)
+		}
+		b.add(fmt.Sprintf("CreateWithExposedPorts(%#v)", exposed))
+	}
+}
+
+func generateNetworks(b *runCommandBuilder, mode container.NetworkMode, nc *network.NetworkingConfig) {
+	switch {
+	case mode.IsUserDefined():
+	case mode.IsDefault() || mode.IsBridge():
+		// docker run take the first --network as network mode, bridge is named explicitly if user networks follow
+		if len(nc.EndpointsConfig) > 0 {
+			b.add(`CreateWithNetworkMode("bridge")`, "--network", "bridge")
+		}
+	default:
+		b.add(fmt.Sprintf("CreateWithNetworkMode(%q)", mode), "--network", string(mode))
+	}
+
+	// primary network first, it becomes the network mode
+	names := sortedKeys(nc.EndpointsConfig)
+	primary := mode.NetworkName()
+	slices.SortStableFunc(names, func(a, b string) int {
+		switch {
+		case a == primary:
+			return -1
+		case b == primary:
+			return 1
+		}
+		return 0
+	})
+	for _, n := range names {
+		e := nc.EndpointsConfig[n]
+		var code []string
+		arg := []string{"name=" + n}
+		for _, a := range e.Aliases {
+			arg = append(arg, "alias="+a)
+		}
+		if len(e.Aliases) > 0 {
+			code = append(code, fmt.Sprintf("EndpointWithAliases(%s)", quoteAll(e.Aliases)))
+		}
+		if e.IPAMConfig != nil {
+			if ip := e.IPAMConfig.IPv4Address; ip != "" {
+				arg = append(arg, "ip="+ip)
+				code = append(code, fmt.Sprintf("EndpointWithIPv4(%q)", ip))
+			}
+			if ip := e.IPAMConfig.IPv6Address; ip != "" {
+				arg = append(arg, "ip6="+ip)
+				code = append(code, fmt.Sprintf("EndpointWithIPv6(%q)", ip))
+			}
+		}
+		if len(e.Links) > 0 {
+			code = append(code, fmt.Sprintf("EndpointWithLinks(%s)", quoteAll(e.Links)))
+			for _, l := range e.Links {
+				b.args = append(b.args, "--link", l)
+			}
+		}
+		if len(arg) == 1 {
+			b.add(fmt.Sprintf("CreateWithNetwork(%q)", n), "--network", n)
+		} else {
+			b.add(fmt.Sprintf("CreateWithNetwork(%q%s)", n, joinCode(code)), "--network", strings.Join(arg, ","))
+		}
+	}
+}
+
+func quoteAll(s []string) string {
+	quoted := make([]string, len(s))
+	for n, v := range s {
+		quoted[n] = strconv.Quote(v)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// generateHealthcheck h is nil if it is the same as the image one
+func generateHealthcheck(b *runCommandBuilder, h *container.HealthConfig) {
+	if h == nil {
+		return
+	}
+	if len(h.Test) > 0 {
+		switch h.Test[0] {
+		case "NONE":
+			b.add("CreateWithNoHealthcheck()", "--no-healthcheck")
+		case "CMD-SHELL":
+			cmd := strings.Join(h.Test[1:], " ")
+			b.add(fmt.Sprintf("CreateWithHealthCmd(%q)", cmd), "--health-cmd", cmd)
+		case "CMD":
+			// docker run --health-cmd always runs with shell
+			b.add(fmt.Sprintf("CreateWithHealthExec(%#v)", h.Test[1:]), "--health-cmd", strings.Join(h.Test[1:], " "))
+		}
+	}
+	if h.Interval != 0 {
+		b.add(fmt.Sprintf("CreateWithHealthInterval(%d)", h.Interval), "--health-interval", h.Interval.String())
+	}
+	if h.Timeout != 0 {
+		b.add(fmt.Sprintf("CreateWithHealthTimeout(%d)", h.Timeout), "--health-timeout", h.Timeout.String())
+	}
+	if h.StartPeriod != 0 {
+		b.add(fmt.Sprintf("CreateWithHealthStartPeriod(%d)", h.StartPeriod), "--health-start-period", h.StartPeriod.String())
+	}
+	if h.Retries != 0 {
+		b.add(fmt.Sprintf("CreateWithHealthRetries(%d)", h.Retries), "--health-retries", strconv.Itoa(h.Retries))
+	}
+}
@@ -0,0 +1,168 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+)
+
+const defaultSelectionConcurrency = 8
+
+// Selection containers matching label selectors, operations run on every matching container in parallel
+// containers are listed again on every operation
+type Selection struct {
+	c           ContainerClient
+	selectors   []string
+	runningOnly bool
+	concurrency int
+}
+
+// SelectionResult result of an operation on one container, Exec is only set by Selection.Exec
+type SelectionResult struct {
+	Id   string
+	Err  error
+	Exec ExecResult
+}
+
+// Select containers having all labels, selector is "key" or "key=value", i.e. Select("app=api", "env=staging")
+// stopped containers are included unless WithRunningOnly is used
+// at least one non-empty selector is required, operations fail instead of acting on every container
+func (c ContainerClient) Select(selectors ...string) *Selection {
+	return &Selection{c: c, selectors: selectors, concurrency: defaultSelectionConcurrency}
+}
+
+// WithConcurrency run at most n operations at the same time, n <= 0 is ignored
+func (s *Selection) WithConcurrency(n int) *Selection {
+	if n > 0 {
+		s.concurrency = n
+	}
+	return s
+}
+
+// WithRunningOnly only select running containers
+func (s *Selection) WithRunningOnly() *Selection {
+	s.runningOnly = true
+	return s
+}
+
+// Containers list containers matching the selection
+func (s *Selection) Containers() ([]types.Container, error) {
+	return s.ContainersContext(context.Background())
+}
+
+func (s *Selection) ContainersContext(ctx context.Context) ([]types.Container, error) {
+	if len(s.selectors) == 0 || slices.Contains(s.selectors, "") {
+		return nil, errors.New("selection requires non-empty label selectors")
+	}
+	// a label filter can be repeated, filter.NewFilterArgs can not express it
+	args := filters.NewArgs()
+	for _, selector := range s.selectors {
+		args.Add("label", selector)
+	}
+	return s.c.c.ContainerList(ctx, types.ContainerListOptions{All: !s.runningOnly, Filters: args})
+}
+
+// run call f on every matching container, the result is keyed by container name
+func (s *Selection) run(ctx context.Context, f func(ctx context.Context, id string) SelectionResult) (map[string]SelectionResult, error) {
+	containers, err := s.ContainersContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	results := make(map[string]SelectionResult, len(containers))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, s.concurrency)
+	for _, i := range containers {
+		name := i.ID
+		if len(i.Names) > 0 {
+			name = strings.TrimPrefix(i.Names[0], "/")
+		}
+		select {
+		case <-ctx.Done():
+			mu.Lock()
+			results[name] = SelectionResult{Id: i.ID, Err: ctx.Err()}
+			mu.Unlock()
+			continue
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func(id, name string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			r := f(ctx, id)
+			r.Id = id
+			mu.Lock()
+			results[name] = r
+			mu.Unlock()
+		}(i.ID, name)
+	}
+	wg.Wait()
+	return results, nil
+}
+
+func (s *Selection) Stop(option TimeoutOption) (map[string]SelectionResult, error) {
+	return s.StopContext(context.Background(), option)
+}
+
+func (s *Selection) StopContext(ctx context.Context, option TimeoutOption) (map[string]SelectionResult, error) {
+	return s.run(ctx, func(ctx context.Context, id string) SelectionResult {
+		return SelectionResult{Err: s.c.StopContext(ctx, id, option)}
+	})
+}
+
+func (s *Selection) Restart(option TimeoutOption) (map[string]SelectionResult, error) {
+	return s.RestartContext(context.Background(), option)
+}
+
+func (s *Selection) RestartContext(ctx context.Context, option TimeoutOption) (map[string]SelectionResult, error) {
+	return s.run(ctx, func(ctx context.Context, id string) SelectionResult {
+		return SelectionResult{Err: s.c.RestartContext(ctx, id, option)}
+	})
+}
+
+func (s *Selection) Remove(options ...RemoveOption) (map[string]SelectionResult, error) {
+	return s.RemoveContext(context.Background(), options...)
+}
+
+func (s *Selection) RemoveContext(ctx context.Context, options ...RemoveOption) (map[string]SelectionResult, error) {
+	return s.run(ctx, func(ctx context.Context, id string) SelectionResult {
+		return SelectionResult{Err: s.c.RemoveContext(ctx, id, options...)}
+	})
+}
+
+func (s *Selection) Pause() (map[string]SelectionResult, error) {
+	return s.PauseContext(context.Background())
+}
+
+func (s *Selection) PauseContext(ctx context.Context) (map[string]SelectionResult, error) {
+	return s.run(ctx, func(ctx context.Context, id string) SelectionResult {
+		return SelectionResult{Err: s.c.PauseContext(ctx, id)}
+	})
+}
+
+func (s *Selection) Unpause() (map[string]SelectionResult, error) {
+	return s.UnpauseContext(context.Background())
+}
+
+func (s *Selection) UnpauseContext(ctx context.Context) (map[string]SelectionResult, error) {
+	return s.run(ctx, func(ctx context.Context, id string) SelectionResult {
+		return SelectionResult{Err: s.c.UnpauseContext(ctx, id)}
+	})
+}
+
+// Exec run cmd in every matching container, a non-zero exit code is reported as *ExitError, see ExecCommand
+func (s *Selection) Exec(cmd []string, options ...ExecConfigOptions) (map[string]SelectionResult, error) {
+	return s.ExecContext(context.Background(), cmd, options...)
+}
+
+func (s *Selection) ExecContext(ctx context.Context, cmd []string, options ...ExecConfigOptions) (map[string]SelectionResult, error) {
+	return s.run(ctx, func(ctx context.Context, id string) SelectionResult {
+		r, err := s.c.ExecCommandContext(ctx, id, cmd, nil, options...)
+		return SelectionResult{Err: err, Exec: r}
+	})
+}
@@ -0,0 +1,142 @@
+package container
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+)
+
+// StatsSample resource usage of a container, computed the same way as `docker stats`
+type StatsSample struct {
+	Read          time.Time
+	CPUPercent    float64
+	MemoryUsage   uint64
+	MemoryLimit   uint64
+	MemoryPercent float64
+	NetworkRx     uint64
+	NetworkTx     uint64
+	BlockRead     uint64
+	BlockWrite    uint64
+	PIDs          uint64
+}
+
+func newStatsSample(s *types.StatsJSON, osType string) StatsSample {
+	sample := StatsSample{Read: s.Read, PIDs: s.PidsStats.Current}
+	for _, n := range s.Networks {
+		sample.NetworkRx += n.RxBytes
+		sample.NetworkTx += n.TxBytes
+	}
+	if osType == "windows" {
+		sample.CPUPercent = windowsCPUPercent(s)
+		sample.MemoryUsage = s.MemoryStats.PrivateWorkingSet
+		sample.BlockRead = s.StorageStats.ReadSizeBytes
+		sample.BlockWrite = s.StorageStats.WriteSizeBytes
+		sample.PIDs = uint64(s.NumProcs)
+		return sample
+	}
+
+	sample.CPUPercent = linuxCPUPercent(s)
+	sample.MemoryUsage = memoryUsage(s.MemoryStats)
+	sample.MemoryLimit = s.MemoryStats.Limit
+	if sample.MemoryLimit != 0 {
+		sample.MemoryPercent = float64(sample.MemoryUsage) / float64(sample.MemoryLimit) * 100
+	}
+	for _, e := range s.BlkioStats.IoServiceBytesRecursive {
+		switch strings.ToLower(e.Op) {
+		case "read":
+			sample.BlockRead += e.Value
+		case "write":
+			sample.BlockWrite += e.Value
+		}
+	}
+	return sample
+}
+
+func linuxCPUPercent(s *types.StatsJSON) float64 {
+	cpuDelta := float64(s.CPUStats.CPUUsage.TotalUsage) - float64(s.PreCPUStats.CPUUsage.TotalUsage)
+	systemDelta := float64(s.CPUStats.SystemUsage) - float64(s.PreCPUStats.SystemUsage)
+	onlineCPUs := float64(s.CPUStats.OnlineCPUs)
+	if onlineCPUs == 0 {
+		onlineCPUs = float64(len(s.CPUStats.CPUUsage.PercpuUsage))
+	}
+	if cpuDelta > 0 && systemDelta > 0 {
+		return cpuDelta / systemDelta * onlineCPUs * 100
+	}
+	return 0
+}
+
+func windowsCPUPercent(s *types.StatsJSON) float64 {
+	// cpu usage is in 100ns intervals
+	possible := uint64(s.Read.Sub(s.PreRead).Nanoseconds()) / 100 * uint64(s.NumProcs)
+	used := s.CPUStats.CPUUsage.TotalUsage - s.PreCPUStats.CPUUsage.TotalUsage
+	if possible > 0 {
+		return float64(used) / float64(possible) * 100
+	}
+	return 0
+}
+
+// memoryUsage exclude page cache, cgroup v1 reports total_inactive_file, cgroup v2 reports inactive_file
+func memoryUsage(m types.MemoryStats) uint64 {
+	if v, ok := m.Stats["total_inactive_file"]; ok && v < m.Usage {
+		return m.Usage - v
+	}
+	if v, ok := m.Stats["inactive_file"]; ok && v < m.Usage {
+		return m.Usage - v
+	}
+	return m.Usage
+}
+
+// StatsOneShot return one typed stats sample, it takes about one second as cpu percent needs two reads
+func (c ContainerClient) StatsOneShot(container string) (StatsSample, error) {
+	return c.StatsOneShotContext(context.Background(), container)
+}
+
+func (c ContainerClient) StatsOneShotContext(ctx context.Context, container string) (StatsSample, error) {
+	r, err := c.c.ContainerStats(ctx, container, false)
+	if err != nil {
+		return StatsSample{}, err
+	}
+	defer r.Body.Close()
+	s := &types.StatsJSON{}
+	if err = json.NewDecoder(r.Body).Decode(s); err != nil {
+		return StatsSample{}, err
+	}
+	return newStatsSample(s, r.OSType), nil
+}
+
+// StatsStream yield a typed stats sample about every second until ctx is done or container stopped
+// samples channel is closed when stream ends, error channel receives at most one error then closed
+func (c ContainerClient) StatsStream(ctx context.Context, container string) (<-chan StatsSample, <-chan error, error) {
+	r, err := c.c.ContainerStats(ctx, container, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	ch := make(chan StatsSample)
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(errCh)
+		defer close(ch)
+		defer r.Body.Close()
+		d := json.NewDecoder(r.Body)
+		for {
+			s := &types.StatsJSON{}
+			if err := d.Decode(s); err != nil {
+				if !errors.Is(err, io.EOF) && ctx.Err() == nil {
+					errCh <- err
+				}
+				return
+			}
+			select {
+			case ch <- newStatsSample(s, r.OSType):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ch, errCh, nil
+}
@@ -0,0 +1,85 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+)
+
+const defaultStopTimeout = 10 * time.Second
+
+// StopStep send Signal and wait up to Timeout for container to exit, Timeout <= 0 wait until exit or ctx is done
+type StopStep struct {
+	Signal  string
+	Timeout time.Duration
+}
+
+type StopResult struct {
+	// Step is index of the step container exited after, -1 if container was not running
+	Step     int
+	Signal   string
+	ExitCode int64
+	Duration time.Duration
+}
+
+// GracefulStop send signals of steps in order until container exits
+// if no step is given, the stop signal and stop timeout of container are used, followed by SIGKILL
+func (c ContainerClient) GracefulStop(container string, steps ...StopStep) (StopResult, error) {
+	return c.GracefulStopContext(context.Background(), container, steps...)
+}
+
+func (c ContainerClient) GracefulStopContext(ctx context.Context, target string, steps ...StopStep) (StopResult, error) {
+	i, _, err := c.InspectContext(ctx, target)
+	if err != nil {
+		return StopResult{}, err
+	}
+	if i.State != nil && !i.State.Running {
+		return StopResult{Step: -1, ExitCode: int64(i.State.ExitCode)}, nil
+	}
+	if len(steps) == 0 {
+		steps = defaultStopSteps(i.Config)
+	}
+
+	// register wait before the first signal, otherwise the exit may be missed
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	statusCh, errCh := c.c.ContainerWait(ctx, i.ID, container.WaitConditionNotRunning)
+	start := time.Now()
+	for n, step := range steps {
+		result := StopResult{Step: n, Signal: step.Signal}
+		if err = c.SignalContext(ctx, i.ID, step.Signal); err != nil {
+			// container may exit between steps, signaling a stopped container fails
+			if si, _, ierr := c.InspectContext(ctx, i.ID); ierr != nil || si.State == nil || si.State.Running {
+				return result, fmt.Errorf("send %s error: %w", step.Signal, err)
+			}
+		}
+		var timeout <-chan time.Time
+		if step.Timeout > 0 {
+			timer := time.NewTimer(step.Timeout)
+			timeout = timer.C
+			defer timer.Stop()
+		}
+		select {
+		case s := <-statusCh:
+			result.ExitCode, result.Duration = s.StatusCode, time.Since(start)
+			return result, nil
+		case err = <-errCh:
+			return result, err
+		case <-timeout:
+		}
+	}
+	return StopResult{Step: len(steps) - 1, Signal: steps[len(steps)-1].Signal}, fmt.Errorf("container %s is still running after %d stop steps", target, len(steps))
+}
+
+func defaultStopSteps(cfg *container.Config) []StopStep {
+	sig, timeout := "SIGTERM", defaultStopTimeout
+	if cfg != nil && cfg.StopSignal != "" {
+		sig = cfg.StopSignal
+	}
+	if cfg != nil && cfg.StopTimeout != nil {
+		timeout = time.Duration(*cfg.StopTimeout) * time.Second
+	}
+	return []StopStep{{Signal: sig, Timeout: timeout}, {Signal: "SIGKILL", Timeout: defaultStopTimeout}}
+}
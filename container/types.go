@@ -1,9 +1,22 @@
 package container
 
 import (
+	"errors"
+	"fmt"
+	"net"
+	"path"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/network"
 
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/go-units"
 	specs "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
@@ -22,3 +35,295 @@ func NewContainerCreateConfig() *ContainerCreateConfig {
 		Platform:      nil,
 	}
 }
+
+type ExecResult struct {
+	ExecId   string
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+// ExitError command exited with a non-zero exit code
+type ExitError struct {
+	ExitCode int
+	Stderr   string
+}
+
+func (e *ExitError) Error() string {
+	if e.Stderr == "" {
+		return fmt.Sprintf("exit code %d", e.ExitCode)
+	}
+	return fmt.Sprintf("exit code %d: %s", e.ExitCode, strings.TrimSpace(e.Stderr))
+}
+
+type WaitCondition string
+
+const (
+	WaitConditionNotRunning WaitCondition = "not-running"
+	WaitConditionNextExit   WaitCondition = "next-exit"
+	WaitConditionRemoved    WaitCondition = "removed"
+)
+
+type LogDriver string
+
+const (
+	LogDriverJsonFile LogDriver = "json-file"
+	LogDriverLocal    LogDriver = "local"
+	LogDriverSyslog   LogDriver = "syslog"
+	LogDriverJournald LogDriver = "journald"
+	LogDriverNone     LogDriver = "none"
+	LogDriverFluentd  LogDriver = "fluentd"
+	LogDriverGelf     LogDriver = "gelf"
+	LogDriverAwslogs  LogDriver = "awslogs"
+)
+
+// logDriverOptions supported options of built-in drivers, options of other drivers are not validated
+// mode and max-buffer-size are handled by the daemon for every driver
+var logDriverOptions = map[LogDriver][]string{
+	LogDriverJsonFile: {"mode", "max-buffer-size", "max-size", "max-file", "compress", "labels", "labels-regex", "env", "env-regex", "tag"},
+	LogDriverLocal:    {"mode", "max-buffer-size", "max-size", "max-file", "compress"},
+	LogDriverNone:     {"mode", "max-buffer-size"},
+	LogDriverSyslog: {
+		"mode", "max-buffer-size", "syslog-address", "syslog-facility", "syslog-tls-ca-cert", "syslog-tls-cert",
+		"syslog-tls-key", "syslog-tls-skip-verify", "syslog-format", "tag", "labels", "labels-regex", "env", "env-regex",
+	},
+	LogDriverJournald: {"mode", "max-buffer-size", "tag", "labels", "labels-regex", "env", "env-regex"},
+}
+
+type RunResult struct {
+	ContainerId string
+	ExitCode    int64
+	Stdout      string
+	Stderr      string
+	Duration    time.Duration
+}
+
+// Validate check config before it is sent to the daemon, all problems are reported together
+func (c *ContainerCreateConfig) Validate() error {
+	var errs []error
+	errs = append(errs, validateMounts(c.HostConfig)...)
+	errs = append(errs, validateHealthcheck(c.Config.Healthcheck)...)
+	errs = append(errs, validateSecurity(c.HostConfig)...)
+	errs = append(errs, validateNetworks(c.HostConfig, c.NetworkConfig)...)
+	errs = append(errs, validateLogConfig(c.HostConfig.LogConfig)...)
+	return errors.Join(errs...)
+}
+
+func validateMounts(h *container.HostConfig) []error {
+	var errs []error
+	targets := make(map[string]bool)
+	for _, b := range h.Binds {
+		// host-src:container-dest[:options]
+		if parts := strings.Split(b, ":"); len(parts) >= 2 {
+			targets[path.Clean(parts[1])] = true
+		}
+	}
+	for _, m := range h.Mounts {
+		if m.Target == "" || !path.IsAbs(m.Target) {
+			errs = append(errs, fmt.Errorf("mount target %q must be an absolute path", m.Target))
+		} else if targets[path.Clean(m.Target)] {
+			errs = append(errs, fmt.Errorf("duplicate mount point %s", m.Target))
+		}
+		targets[path.Clean(m.Target)] = true
+
+		switch m.Type {
+		case mount.TypeBind:
+			if m.Source == "" || !filepath.IsAbs(m.Source) {
+				errs = append(errs, fmt.Errorf("bind mount source %q must be an absolute path", m.Source))
+			}
+			if m.BindOptions != nil && m.BindOptions.Propagation != "" && !slices.Contains(mount.Propagations, m.BindOptions.Propagation) {
+				errs = append(errs, fmt.Errorf("invalid propagation %q of mount %s", m.BindOptions.Propagation, m.Target))
+			}
+		case mount.TypeVolume:
+			if m.Source != "" && strings.ContainsAny(m.Source, `/\`) {
+				errs = append(errs, fmt.Errorf("volume name %q is invalid, use bind mount for host paths", m.Source))
+			}
+		case mount.TypeTmpfs:
+			if m.Source != "" {
+				errs = append(errs, fmt.Errorf("tmpfs mount %s must not have a source", m.Target))
+			}
+			if m.TmpfsOptions != nil && m.TmpfsOptions.SizeBytes < 0 {
+				errs = append(errs, fmt.Errorf("tmpfs size of mount %s must not be negative", m.Target))
+			}
+		case mount.TypeNamedPipe, mount.TypeCluster:
+		default:
+			errs = append(errs, fmt.Errorf("unknown mount type %q of mount %s", m.Type, m.Target))
+		}
+
+		if m.BindOptions != nil && m.Type != mount.TypeBind {
+			errs = append(errs, fmt.Errorf("bind options are only allowed on bind mount, got %s mount %s", m.Type, m.Target))
+		}
+		if m.VolumeOptions != nil && m.Type != mount.TypeVolume {
+			errs = append(errs, fmt.Errorf("volume options are only allowed on volume mount, got %s mount %s", m.Type, m.Target))
+		}
+		if m.TmpfsOptions != nil && m.Type != mount.TypeTmpfs {
+			errs = append(errs, fmt.Errorf("tmpfs options are only allowed on tmpfs mount, got %s mount %s", m.Type, m.Target))
+		}
+	}
+	return errs
+}
+
+// minimumHealthDuration the daemon rejects non-zero healthcheck durations less than 1ms
+const minimumHealthDuration = time.Millisecond
+
+func validateHealthcheck(h *container.HealthConfig) []error {
+	if h == nil {
+		return nil
+	}
+	var errs []error
+	if len(h.Test) > 0 {
+		switch h.Test[0] {
+		case "NONE":
+			if len(h.Test) > 1 {
+				errs = append(errs, errors.New("healthcheck NONE must not have arguments"))
+			}
+		case "CMD", "CMD-SHELL":
+			if len(h.Test) < 2 || strings.TrimSpace(strings.Join(h.Test[1:], "")) == "" {
+				errs = append(errs, errors.New("healthcheck command is empty"))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("unknown healthcheck type %q", h.Test[0]))
+		}
+	}
+	durations := []struct {
+		name string
+		d    time.Duration
+	}{{"interval", h.Interval}, {"timeout", h.Timeout}, {"start period", h.StartPeriod}}
+	for _, i := range durations {
+		if i.d != 0 && i.d < minimumHealthDuration {
+			errs = append(errs, fmt.Errorf("healthcheck %s must be at least %s", i.name, minimumHealthDuration))
+		}
+	}
+	if h.Retries < 0 {
+		errs = append(errs, errors.New("healthcheck retries must not be negative"))
+	}
+	return errs
+}
+
+func validateSecurity(h *container.HostConfig) []error {
+	var errs []error
+	if h.Privileged && slices.Contains(h.CapDrop, "ALL") {
+		errs = append(errs, errors.New("privileged container can not drop all capabilities"))
+	}
+	for _, u := range h.Ulimits {
+		if u.Soft > u.Hard {
+			errs = append(errs, fmt.Errorf("ulimit %s soft limit %d is greater than hard limit %d", u.Name, u.Soft, u.Hard))
+		}
+	}
+	return errs
+}
+
+func validateNetworks(h *container.HostConfig, n *network.NetworkingConfig) []error {
+	var errs []error
+	mode := h.NetworkMode
+	if len(n.EndpointsConfig) > 0 && (mode.IsHost() || mode.IsNone() || mode.IsContainer()) {
+		errs = append(errs, fmt.Errorf("network mode %s can not attach to networks", mode))
+	}
+	for name, e := range n.EndpointsConfig {
+		if e == nil || e.IPAMConfig == nil {
+			continue
+		}
+		if ip := e.IPAMConfig.IPv4Address; ip != "" {
+			if addr := net.ParseIP(ip); addr == nil || addr.To4() == nil {
+				errs = append(errs, fmt.Errorf("invalid ipv4 address %q on network %s", ip, name))
+			}
+		}
+		if ip := e.IPAMConfig.IPv6Address; ip != "" {
+			if addr := net.ParseIP(ip); addr == nil || addr.To4() != nil {
+				errs = append(errs, fmt.Errorf("invalid ipv6 address %q on network %s", ip, name))
+			}
+		}
+	}
+	for _, dns := range h.DNS {
+		if net.ParseIP(dns) == nil {
+			errs = append(errs, fmt.Errorf("invalid dns server %q", dns))
+		}
+	}
+	for _, host := range h.ExtraHosts {
+		// ipv6 address contains ":", split at the first one
+		name, ip, found := strings.Cut(host, ":")
+		if !found || name == "" || (ip != "host-gateway" && net.ParseIP(ip) == nil) {
+			errs = append(errs, fmt.Errorf("invalid extra host %q, format is hostname:ip", host))
+		}
+	}
+	return errs
+}
+
+func validateLogConfig(l container.LogConfig) []error {
+	supported, builtin := logDriverOptions[LogDriver(l.Type)]
+	if !builtin {
+		return nil
+	}
+	var errs []error
+	keys := make([]string, 0, len(l.Config))
+	for k := range l.Config {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for _, k := range keys {
+		v := l.Config[k]
+		if !slices.Contains(supported, k) {
+			errs = append(errs, fmt.Errorf("unknown log option %s for %s log driver", k, l.Type))
+			continue
+		}
+		switch k {
+		case "mode":
+			if m := container.LogMode(v); m != container.LogModeBlocking && m != container.LogModeNonBlock {
+				errs = append(errs, fmt.Errorf("invalid log option mode %q, must be blocking or non-blocking", v))
+			}
+		case "max-buffer-size":
+			if container.LogMode(l.Config["mode"]) != container.LogModeNonBlock {
+				errs = append(errs, errors.New("log option max-buffer-size is only supported with mode non-blocking"))
+			}
+			if n, err := units.RAMInBytes(v); err != nil || n <= 0 {
+				errs = append(errs, fmt.Errorf("invalid log option max-buffer-size %q", v))
+			}
+		case "max-size":
+			// json-file accepts -1 as unlimited
+			if v == "-1" && LogDriver(l.Type) == LogDriverJsonFile {
+				continue
+			}
+			if n, err := units.RAMInBytes(v); err != nil || n <= 0 {
+				errs = append(errs, fmt.Errorf("invalid log option max-size %q", v))
+			}
+		case "max-file":
+			if n, err := strconv.Atoi(v); err != nil || n < 1 {
+				errs = append(errs, fmt.Errorf("invalid log option max-file %q, must be a positive integer", v))
+			}
+		case "compress":
+			if _, err := strconv.ParseBool(v); err != nil {
+				errs = append(errs, fmt.Errorf("invalid log option compress %q, must be a boolean", v))
+			}
+		}
+	}
+	return errs
+}
+
+// splitNetworks the daemon only accepts one network when creating container
+// return config of the primary network(network mode) and the rest networks to be connected after creation
+// networks of a builtin mode, i.e. user networks of a bridge container, are all connected after creation
+func (c *ContainerCreateConfig) splitNetworks() (*network.NetworkingConfig, map[string]*network.EndpointSettings) {
+	mode := c.HostConfig.NetworkMode
+	if len(c.NetworkConfig.EndpointsConfig) <= 1 && (mode == "" || mode.IsUserDefined()) {
+		return c.NetworkConfig, nil
+	}
+	primary := mode.NetworkName()
+	others := make(map[string]*network.EndpointSettings)
+	nc := &network.NetworkingConfig{EndpointsConfig: make(map[string]*network.EndpointSettings)}
+	for name, e := range c.NetworkConfig.EndpointsConfig {
+		if name == primary {
+			nc.EndpointsConfig[name] = e
+		} else {
+			others[name] = e
+		}
+	}
+	return nc, others
+}
+
+type HealthState struct {
+	// Status is one of none, starting, healthy or unhealthy
+	Status        string
+	FailingStreak int
+	// Log is the last probe results, oldest first
+	Log []*types.HealthcheckResult
+}
@@ -0,0 +1,232 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+
+	"github.com/riete/docker/common/reader"
+)
+
+const (
+	waitPollInterval   = 500 * time.Millisecond
+	waitDialTimeout    = time.Second
+	readyErrorLogLines = 20
+)
+
+// WaitStrategy block until container is ready, ctx is done or container can never be ready
+type WaitStrategy func(ctx context.Context, c ContainerClient, container string) error
+
+// ReadyError container is not ready, Logs is the last lines of container logs
+type ReadyError struct {
+	Container string
+	Err       error
+	Logs      []string
+}
+
+func (e *ReadyError) Error() string {
+	msg := fmt.Sprintf("container %s is not ready: %v", e.Container, e.Err)
+	if len(e.Logs) > 0 {
+		msg += "\nlast logs:\n" + strings.Join(e.Logs, "\n")
+	}
+	return msg
+}
+
+func (e *ReadyError) Unwrap() error {
+	return e.Err
+}
+
+// poll call check until it is done, the error of last not done check is reported when ctx is done
+func poll(ctx context.Context, check func() (bool, error)) error {
+	for {
+		done, err := check()
+		if done {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			if err != nil {
+				return fmt.Errorf("%w, last error: %v", ctx.Err(), err)
+			}
+			return ctx.Err()
+		case <-time.After(waitPollInterval):
+		}
+	}
+}
+
+// notRunning return an error if container is exited or dead, it can never be ready in that case
+func notRunning(i types.ContainerJSON) error {
+	if i.State == nil || i.State.Running || i.State.Status == "created" || i.State.Restarting {
+		return nil
+	}
+	return fmt.Errorf("container is %s, exit code %d", i.State.Status, i.State.ExitCode)
+}
+
+// WaitForHealthy wait until health status becomes healthy, container must have a healthcheck
+func WaitForHealthy() WaitStrategy {
+	return func(ctx context.Context, c ContainerClient, container string) error {
+		return poll(ctx, func() (bool, error) {
+			i, _, err := c.InspectContext(ctx, container)
+			if err != nil {
+				return false, err
+			}
+			if err = notRunning(i); err != nil {
+				return true, err
+			}
+			if i.State.Health == nil {
+				return true, errors.New("container has no healthcheck")
+			}
+			if i.State.Health.Status == types.Healthy {
+				return true, nil
+			}
+			return false, fmt.Errorf("health status is %s", i.State.Health.Status)
+		})
+	}
+}
+
+// WaitForLog wait until pattern matches container logs(stdout and stderr)
+func WaitForLog(pattern *regexp.Regexp) WaitStrategy {
+	return func(ctx context.Context, c ContainerClient, container string) error {
+		return poll(ctx, func() (bool, error) {
+			i, _, err := c.InspectContext(ctx, container)
+			if err != nil {
+				return false, err
+			}
+			logs, err := c.logsText(ctx, container, i.Config.Tty)
+			if err != nil {
+				return false, err
+			}
+			if pattern.MatchString(logs) {
+				return true, nil
+			}
+			if err = notRunning(i); err != nil {
+				return true, err
+			}
+			return false, fmt.Errorf("pattern %q not found in logs", pattern.String())
+		})
+	}
+}
+
+// WaitForPort wait until published host port of port(i.e. 5432/tcp) is reachable
+func WaitForPort(port string) WaitStrategy {
+	return func(ctx context.Context, c ContainerClient, container string) error {
+		return poll(ctx, func() (bool, error) {
+			ip, hostPort, done, err := c.hostPort(ctx, container, port)
+			if err != nil {
+				return done, err
+			}
+			addr := net.JoinHostPort(ip, hostPort)
+			d := net.Dialer{Timeout: waitDialTimeout}
+			conn, err := d.DialContext(ctx, "tcp", addr)
+			if err != nil {
+				return false, err
+			}
+			_ = conn.Close()
+			return true, nil
+		})
+	}
+}
+
+// WaitForHTTP wait until GET http://{published host port of port}{path} returns 2xx
+func WaitForHTTP(port, path string) WaitStrategy {
+	return func(ctx context.Context, c ContainerClient, container string) error {
+		hc := &http.Client{Timeout: waitDialTimeout}
+		return poll(ctx, func() (bool, error) {
+			ip, hostPort, done, err := c.hostPort(ctx, container, port)
+			if err != nil {
+				return done, err
+			}
+			addr := net.JoinHostPort(ip, hostPort)
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+addr+path, nil)
+			if err != nil {
+				return true, err
+			}
+			r, err := hc.Do(req)
+			if err != nil {
+				return false, err
+			}
+			_ = r.Body.Close()
+			if r.StatusCode >= 200 && r.StatusCode < 300 {
+				return true, nil
+			}
+			return false, fmt.Errorf("http status is %s", r.Status)
+		})
+	}
+}
+
+// WaitForExec wait until cmd executed in container exits with 0
+func WaitForExec(cmd []string) WaitStrategy {
+	return func(ctx context.Context, c ContainerClient, container string) error {
+		return poll(ctx, func() (bool, error) {
+			_, err := c.ExecCommandContext(ctx, container, cmd, nil)
+			return err == nil, err
+		})
+	}
+}
+
+// WaitForAll wait strategies one by one
+func WaitForAll(strategies ...WaitStrategy) WaitStrategy {
+	return func(ctx context.Context, c ContainerClient, container string) error {
+		for _, strategy := range strategies {
+			if err := strategy(ctx, c, container); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// WaitWithTimeout limit the time strategy can wait
+func WaitWithTimeout(timeout time.Duration, strategy WaitStrategy) WaitStrategy {
+	return func(ctx context.Context, c ContainerClient, container string) error {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return strategy(ctx, c, container)
+	}
+}
+
+func (c ContainerClient) logsText(ctx context.Context, container string, tty bool, options ...LogsOption) (string, error) {
+	r, err := c.LogsContext(ctx, container, options...)
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	if tty {
+		var b strings.Builder
+		_, err = io.Copy(&b, r)
+		return b.String(), err
+	}
+	return reader.ParseToCombinedOutput(r)
+}
+
+// WaitReady block until all strategies are satisfied, strategies are applied one by one
+// return *ReadyError with the last container logs on failure, use WaitWithTimeout or ctx to limit waiting time
+func (c ContainerClient) WaitReady(container string, strategies ...WaitStrategy) error {
+	return c.WaitReadyContext(context.Background(), container, strategies...)
+}
+
+func (c ContainerClient) WaitReadyContext(ctx context.Context, container string, strategies ...WaitStrategy) error {
+	err := WaitForAll(strategies...)(ctx, c, container)
+	if err == nil {
+		return nil
+	}
+	e := &ReadyError{Container: container, Err: err}
+	// ctx may be done already, fetching logs must not depend on it
+	lctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if i, _, ierr := c.InspectContext(lctx, container); ierr == nil {
+		logs, _ := c.logsText(lctx, container, i.Config.Tty, LogsWithTail(fmt.Sprintf("%d", readyErrorLogLines)))
+		if logs = strings.TrimRight(logs, "\n"); logs != "" {
+			e.Logs = strings.Split(logs, "\n")
+		}
+	}
+	return e
+}
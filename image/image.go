@@ -9,7 +9,6 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-	"time"
 
 	"github.com/riete/convert/str"
 
@@ -17,9 +16,10 @@ import (
 
 	"github.com/riete/docker/common/filter"
 
-	"github.com/riete/archive/tar"
+	"github.com/riete/docker/common/tarstream"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/client"
 )
 
@@ -27,8 +27,8 @@ type ImageClient struct {
 	c *client.Client
 }
 
-func (i ImageClient) getImageByName(name string) (types.ImageSummary, error) {
-	s, err := i.List(ListWithFilters(map[string]string{"reference": name}))
+func (i ImageClient) getImageByName(ctx context.Context, name string) (types.ImageSummary, error) {
+	s, err := i.ListContext(ctx, ListWithFilters(map[string]string{"reference": name}))
 	if err != nil {
 		return types.ImageSummary{}, err
 	}
@@ -39,23 +39,34 @@ func (i ImageClient) getImageByName(name string) (types.ImageSummary, error) {
 }
 
 func (i ImageClient) List(options ...ListOption) ([]types.ImageSummary, error) {
+	return i.ListContext(context.Background(), options...)
+}
+
+func (i ImageClient) ListContext(ctx context.Context, options ...ListOption) ([]types.ImageSummary, error) {
 	o := types.ImageListOptions{}
 	for _, option := range options {
 		option(&o)
 	}
-	return i.c.ImageList(context.Background(), o)
+	if err := filter.Validate(filter.ImageList, o.Filters); err != nil {
+		return nil, err
+	}
+	return i.c.ImageList(ctx, o)
 }
 
 // Inspect target can image name(repo:tag) or id
 func (i ImageClient) Inspect(target string) (types.ImageInspect, string, error) {
+	return i.InspectContext(context.Background(), target)
+}
+
+func (i ImageClient) InspectContext(ctx context.Context, target string) (types.ImageInspect, string, error) {
 	if strings.Contains(target, "/") {
-		image, err := i.getImageByName(target)
+		image, err := i.getImageByName(ctx, target)
 		if err != nil {
 			return types.ImageInspect{}, "", err
 		}
 		target = image.ID
 	}
-	inspect, b, err := i.c.ImageInspectWithRaw(context.Background(), target)
+	inspect, b, err := i.c.ImageInspectWithRaw(ctx, target)
 	return inspect, str.FromBytes(b), err
 }
 
@@ -89,22 +100,12 @@ func (i ImageClient) Build(ctx context.Context, path string, options ...BuildOpt
 		return nil, errors.New(fmt.Sprintf("%s is not exists", dockerfile))
 	}
 
-	// create build context
-	tarFile := filepath.Join(os.TempDir(), fmt.Sprintf("%d.tar", time.Now().Unix()))
-	packer := tar.NewTarPacker(tarFile, path, false, ".")
-	if err := packer.Pack(); err != nil {
-		return nil, err
-	}
-	defer os.Remove(tarFile)
+	// build context is archived as a tar stream on the fly
+	buildContext := tarstream.FromPath(path)
+	defer buildContext.Close()
+	o.Context = buildContext
 
-	f, err := os.Open(tarFile)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
-	o.Context = f
-
-	r, err := i.c.ImageBuild(ctx, f, o)
+	r, err := i.c.ImageBuild(ctx, buildContext, o)
 	if err != nil {
 		return nil, err
 	}
@@ -112,12 +113,20 @@ func (i ImageClient) Build(ctx context.Context, path string, options ...BuildOpt
 }
 
 func (i ImageClient) Tag(src, tgt string) error {
-	return i.c.ImageTag(context.Background(), src, tgt)
+	return i.TagContext(context.Background(), src, tgt)
+}
+
+func (i ImageClient) TagContext(ctx context.Context, src, tgt string) error {
+	return i.c.ImageTag(ctx, src, tgt)
 }
 
 func (i ImageClient) Remove(target string, options ...RemoveOption) ([]types.ImageDeleteResponseItem, error) {
+	return i.RemoveContext(context.Background(), target, options...)
+}
+
+func (i ImageClient) RemoveContext(ctx context.Context, target string, options ...RemoveOption) ([]types.ImageDeleteResponseItem, error) {
 	if strings.Contains(target, "/") {
-		image, err := i.getImageByName(target)
+		image, err := i.getImageByName(ctx, target)
 		if err != nil {
 			return nil, err
 		}
@@ -129,21 +138,41 @@ func (i ImageClient) Remove(target string, options ...RemoveOption) ([]types.Ima
 		option(&o)
 	}
 
-	return i.c.ImageRemove(context.Background(), target, o)
+	return i.c.ImageRemove(ctx, target, o)
 }
 
 // Prune remove unused image
 func (i ImageClient) Prune(options ...PruneOption) (types.ImagesPruneReport, error) {
+	return i.PruneContext(context.Background(), options...)
+}
+
+func (i ImageClient) PruneContext(ctx context.Context, options ...PruneOption) (types.ImagesPruneReport, error) {
 	f := make(map[string]string)
 	for _, option := range options {
 		option(f)
 	}
-	return i.c.ImagesPrune(context.Background(), filter.NewFilterArgs(f))
+	return i.c.ImagesPrune(ctx, filter.NewFilterArgs(f))
+}
+
+// PruneByFilter prune with filters built by filter.New, a key can have multiple values, i.e. several label filters
+func (i ImageClient) PruneByFilter(args filters.Args) (types.ImagesPruneReport, error) {
+	return i.PruneByFilterContext(context.Background(), args)
+}
+
+func (i ImageClient) PruneByFilterContext(ctx context.Context, args filters.Args) (types.ImagesPruneReport, error) {
+	if err := filter.Validate(filter.ImagePrune, args); err != nil {
+		return types.ImagesPruneReport{}, err
+	}
+	return i.c.ImagesPrune(ctx, args)
 }
 
 // Save save image as a tar file
 func (i ImageClient) Save(image, saveTo string) error {
-	r, err := i.c.ImageSave(context.Background(), []string{image})
+	return i.SaveContext(context.Background(), image, saveTo)
+}
+
+func (i ImageClient) SaveContext(ctx context.Context, image, saveTo string) error {
+	r, err := i.c.ImageSave(ctx, []string{image})
 	if err != nil {
 		return err
 	}
@@ -158,11 +187,15 @@ func (i ImageClient) Save(image, saveTo string) error {
 
 // Load load image form a tar file, ensure to close io.ReadCloser
 func (i ImageClient) Load(loadFrom string) (io.ReadCloser, error) {
+	return i.LoadContext(context.Background(), loadFrom)
+}
+
+func (i ImageClient) LoadContext(ctx context.Context, loadFrom string) (io.ReadCloser, error) {
 	f, err := os.Open(loadFrom)
 	if err != nil {
 		return nil, err
 	}
-	r, err := i.c.ImageLoad(context.Background(), f, false)
+	r, err := i.c.ImageLoad(ctx, f, false)
 	if err != nil {
 		return nil, err
 	}
@@ -170,12 +203,22 @@ func (i ImageClient) Load(loadFrom string) (io.ReadCloser, error) {
 }
 
 func (i ImageClient) History(image string) ([]image.HistoryResponseItem, error) {
-	return i.c.ImageHistory(context.Background(), image)
+	return i.HistoryContext(context.Background(), image)
+}
+
+func (i ImageClient) HistoryContext(ctx context.Context, image string) ([]image.HistoryResponseItem, error) {
+	return i.c.ImageHistory(ctx, image)
 }
 
 func NewImageClient() (*ImageClient, error) {
-	var err error
-	c := &ImageClient{}
-	c.c, err = client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
-	return c, err
+	c, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, err
+	}
+	return NewImageClientFromClient(c), nil
+}
+
+// NewImageClientFromClient wrap an existing engine client, the connection is shared and not owned by ImageClient
+func NewImageClientFromClient(c *client.Client) *ImageClient {
+	return &ImageClient{c: c}
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/riete/docker/common/filter"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
 )
 
 type BuildOption func(*types.ImageBuildOptions)
@@ -83,6 +84,13 @@ func ListWithFilters(f map[string]string) ListOption {
 	}
 }
 
+// ListWithFilterArgs use filters built by filter.New, a key can have multiple values, keys are validated by List
+func ListWithFilterArgs(f filters.Args) ListOption {
+	return func(o *types.ImageListOptions) {
+		o.Filters = f
+	}
+}
+
 type AuthOption func(*types.ImagePullOptions)
 
 func PullPushWithAuth(username, password string) AuthOption {
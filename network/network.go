@@ -8,6 +8,7 @@ import (
 	"github.com/riete/docker/common/filter"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/client"
 )
 
@@ -16,47 +17,88 @@ type NetworkClient struct {
 }
 
 func (n NetworkClient) List(options ...ListOption) ([]types.NetworkResource, error) {
+	return n.ListContext(context.Background(), options...)
+}
+
+func (n NetworkClient) ListContext(ctx context.Context, options ...ListOption) ([]types.NetworkResource, error) {
 	o := types.NetworkListOptions{}
 	for _, option := range options {
 		option(&o)
 	}
-	return n.c.NetworkList(context.Background(), o)
+	if err := filter.Validate(filter.NetworkList, o.Filters); err != nil {
+		return nil, err
+	}
+	return n.c.NetworkList(ctx, o)
 }
 
 // Inspect target can network name or id
 func (n NetworkClient) Inspect(target string, options ...InspectOption) (types.NetworkResource, string, error) {
+	return n.InspectContext(context.Background(), target, options...)
+}
+
+func (n NetworkClient) InspectContext(ctx context.Context, target string, options ...InspectOption) (types.NetworkResource, string, error) {
 	o := types.NetworkInspectOptions{}
 	for _, option := range options {
 		option(&o)
 	}
-	r, b, err := n.c.NetworkInspectWithRaw(context.Background(), target, o)
+	r, b, err := n.c.NetworkInspectWithRaw(ctx, target, o)
 	return r, str.FromBytes(b), err
 }
 
 func (n NetworkClient) Create(name string, options ...CreateOption) (types.NetworkCreateResponse, error) {
+	return n.CreateContext(context.Background(), name, options...)
+}
+
+func (n NetworkClient) CreateContext(ctx context.Context, name string, options ...CreateOption) (types.NetworkCreateResponse, error) {
 	o := types.NetworkCreate{CheckDuplicate: false}
 	for _, option := range options {
 		option(&o)
 	}
-	return n.c.NetworkCreate(context.Background(), name, o)
+	return n.c.NetworkCreate(ctx, name, o)
 }
 
 func (n NetworkClient) Remove(target string) error {
-	return n.c.NetworkRemove(context.Background(), target)
+	return n.RemoveContext(context.Background(), target)
+}
+
+func (n NetworkClient) RemoveContext(ctx context.Context, target string) error {
+	return n.c.NetworkRemove(ctx, target)
 }
 
 // Prune remove unused network
 func (n NetworkClient) Prune(options ...PruneOption) (types.NetworksPruneReport, error) {
+	return n.PruneContext(context.Background(), options...)
+}
+
+func (n NetworkClient) PruneContext(ctx context.Context, options ...PruneOption) (types.NetworksPruneReport, error) {
 	f := make(map[string]string)
 	for _, option := range options {
 		option(f)
 	}
-	return n.c.NetworksPrune(context.Background(), filter.NewFilterArgs(f))
+	return n.c.NetworksPrune(ctx, filter.NewFilterArgs(f))
+}
+
+// PruneByFilter prune with filters built by filter.New, a key can have multiple values, i.e. several label filters
+func (n NetworkClient) PruneByFilter(args filters.Args) (types.NetworksPruneReport, error) {
+	return n.PruneByFilterContext(context.Background(), args)
+}
+
+func (n NetworkClient) PruneByFilterContext(ctx context.Context, args filters.Args) (types.NetworksPruneReport, error) {
+	if err := filter.Validate(filter.NetworkPrune, args); err != nil {
+		return types.NetworksPruneReport{}, err
+	}
+	return n.c.NetworksPrune(ctx, args)
 }
 
 func NewNetworkClient() (*NetworkClient, error) {
-	var err error
-	n := &NetworkClient{}
-	n.c, err = client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
-	return n, err
+	c, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, err
+	}
+	return NewNetworkClientFromClient(c), nil
+}
+
+// NewNetworkClientFromClient wrap an existing engine client, the connection is shared and not owned by NetworkClient
+func NewNetworkClientFromClient(c *client.Client) *NetworkClient {
+	return &NetworkClient{c: c}
 }
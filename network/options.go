@@ -2,6 +2,7 @@ package network
 
 import (
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/network"
 	"github.com/riete/docker/common/filter"
 )
@@ -28,6 +29,13 @@ func ListWithFilters(f map[string]string) ListOption {
 	}
 }
 
+// ListWithFilterArgs use filters built by filter.New, a key can have multiple values, keys are validated by List
+func ListWithFilterArgs(f filters.Args) ListOption {
+	return func(o *types.NetworkListOptions) {
+		o.Filters = f
+	}
+}
+
 type InspectOption func(*types.NetworkInspectOptions)
 
 func InspectWithVerbose() InspectOption {
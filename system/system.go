@@ -21,8 +21,12 @@ type SystemClient struct {
 // note this function actually do not make docker daemon login to the registry
 // User Login to login to the registry
 func (s SystemClient) RegistryLogin(addr, username, password string) (registry.AuthenticateOKBody, error) {
+	return s.RegistryLoginContext(context.Background(), addr, username, password)
+}
+
+func (s SystemClient) RegistryLoginContext(ctx context.Context, addr, username, password string) (registry.AuthenticateOKBody, error) {
 	return s.c.RegistryLogin(
-		context.Background(),
+		ctx,
 		registry.AuthConfig{
 			Username:      username,
 			Password:      password,
@@ -55,16 +59,27 @@ func (s SystemClient) PingContext(ctx context.Context) (types.Ping, error) {
 }
 
 func (s SystemClient) Info() (types.Info, error) {
-	return s.InfoContex(context.Background())
+	return s.InfoContext(context.Background())
 }
 
-func (s SystemClient) InfoContex(ctx context.Context) (types.Info, error) {
+func (s SystemClient) InfoContext(ctx context.Context) (types.Info, error) {
 	return s.c.Info(ctx)
 }
 
+// InfoContex misspelled alias of InfoContext
+//
+// Deprecated: use InfoContext
+func (s SystemClient) InfoContex(ctx context.Context) (types.Info, error) {
+	return s.InfoContext(ctx)
+}
+
 // DiskUsage types.DiskUsage is original data, DiskUsageSummary show images, containers and local volumes usage
 func (s SystemClient) DiskUsage() (types.DiskUsage, DiskUsageSummary, error) {
-	r, err := s.c.DiskUsage(context.Background(), types.DiskUsageOptions{})
+	return s.DiskUsageContext(context.Background())
+}
+
+func (s SystemClient) DiskUsageContext(ctx context.Context) (types.DiskUsage, DiskUsageSummary, error) {
+	r, err := s.c.DiskUsage(ctx, types.DiskUsageOptions{})
 	if err != nil {
 		return r, DiskUsageSummary{}, err
 	}
@@ -74,8 +89,14 @@ func (s SystemClient) DiskUsage() (types.DiskUsage, DiskUsageSummary, error) {
 }
 
 func NewSystemClient() (*SystemClient, error) {
-	var err error
-	s := &SystemClient{}
-	s.c, err = client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
-	return s, err
+	c, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, err
+	}
+	return NewSystemClientFromClient(c), nil
+}
+
+// NewSystemClientFromClient wrap an existing engine client, the connection is shared and not owned by SystemClient
+func NewSystemClientFromClient(c *client.Client) *SystemClient {
+	return &SystemClient{c: c}
 }
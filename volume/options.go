@@ -1,6 +1,7 @@
 package volume
 
 import (
+	"github.com/docker/docker/api/types/filters"
 	volumetypes "github.com/docker/docker/api/types/volume"
 	"github.com/riete/docker/common/filter"
 )
@@ -13,6 +14,13 @@ func ListWithFilters(f map[string]string) ListOption {
 	}
 }
 
+// ListWithFilterArgs use filters built by filter.New, a key can have multiple values, keys are validated by List
+func ListWithFilterArgs(f filters.Args) ListOption {
+	return func(o *volumetypes.ListOptions) {
+		o.Filters = f
+	}
+}
+
 type CreateOption func(options *volumetypes.CreateOptions)
 
 func CreateWithDriver(driver string) CreateOption {
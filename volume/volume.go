@@ -8,6 +8,7 @@ import (
 	"github.com/riete/convert/str"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
 
 	volumetypes "github.com/docker/docker/api/types/volume"
 	"github.com/docker/docker/client"
@@ -19,45 +20,86 @@ type VolumeClient struct {
 }
 
 func (v VolumeClient) List(options ...ListOption) (volumetypes.ListResponse, error) {
+	return v.ListContext(context.Background(), options...)
+}
+
+func (v VolumeClient) ListContext(ctx context.Context, options ...ListOption) (volumetypes.ListResponse, error) {
 	o := volumetypes.ListOptions{}
 	for _, option := range options {
 		option(&o)
 	}
-	return v.c.VolumeList(context.Background(), o)
+	if err := filter.Validate(filter.VolumeList, o.Filters); err != nil {
+		return volumetypes.ListResponse{}, err
+	}
+	return v.c.VolumeList(ctx, o)
 }
 
 func (v VolumeClient) Inspect(volume string) (volumetypes.Volume, string, error) {
-	r, b, err := v.c.VolumeInspectWithRaw(context.Background(), volume)
+	return v.InspectContext(context.Background(), volume)
+}
+
+func (v VolumeClient) InspectContext(ctx context.Context, volume string) (volumetypes.Volume, string, error) {
+	r, b, err := v.c.VolumeInspectWithRaw(ctx, volume)
 	return r, str.FromBytes(b), err
 }
 
 func (v VolumeClient) Create(volumeName string, options ...CreateOption) (volumetypes.Volume, error) {
-	if _, _, err := v.Inspect(volumeName); err == nil {
+	return v.CreateContext(context.Background(), volumeName, options...)
+}
+
+func (v VolumeClient) CreateContext(ctx context.Context, volumeName string, options ...CreateOption) (volumetypes.Volume, error) {
+	if _, _, err := v.InspectContext(ctx, volumeName); err == nil {
 		return volumetypes.Volume{}, errors.New(fmt.Sprintf(`Conflict: the volume name "%s" is already exists`, volumeName))
 	}
 	o := volumetypes.CreateOptions{Name: volumeName}
 	for _, option := range options {
 		option(&o)
 	}
-	return v.c.VolumeCreate(context.Background(), o)
+	return v.c.VolumeCreate(ctx, o)
 }
 
 func (v VolumeClient) Remove(volume string, force bool) error {
-	return v.c.VolumeRemove(context.Background(), volume, force)
+	return v.RemoveContext(context.Background(), volume, force)
+}
+
+func (v VolumeClient) RemoveContext(ctx context.Context, volume string, force bool) error {
+	return v.c.VolumeRemove(ctx, volume, force)
 }
 
 // Prune remove used volumes
 func (v VolumeClient) Prune(options ...PruneOption) (types.VolumesPruneReport, error) {
+	return v.PruneContext(context.Background(), options...)
+}
+
+func (v VolumeClient) PruneContext(ctx context.Context, options ...PruneOption) (types.VolumesPruneReport, error) {
 	f := make(map[string]string)
 	for _, option := range options {
 		option(f)
 	}
-	return v.c.VolumesPrune(context.Background(), filter.NewFilterArgs(f))
+	return v.c.VolumesPrune(ctx, filter.NewFilterArgs(f))
+}
+
+// PruneByFilter prune with filters built by filter.New, a key can have multiple values, i.e. several label filters
+func (v VolumeClient) PruneByFilter(args filters.Args) (types.VolumesPruneReport, error) {
+	return v.PruneByFilterContext(context.Background(), args)
+}
+
+func (v VolumeClient) PruneByFilterContext(ctx context.Context, args filters.Args) (types.VolumesPruneReport, error) {
+	if err := filter.Validate(filter.VolumePrune, args); err != nil {
+		return types.VolumesPruneReport{}, err
+	}
+	return v.c.VolumesPrune(ctx, args)
 }
 
 func NewVolumeClient() (*VolumeClient, error) {
-	var err error
-	v := &VolumeClient{}
-	v.c, err = client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
-	return v, err
+	c, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
+	if err != nil {
+		return nil, err
+	}
+	return NewVolumeClientFromClient(c), nil
+}
+
+// NewVolumeClientFromClient wrap an existing engine client, the connection is shared and not owned by VolumeClient
+func NewVolumeClientFromClient(c *client.Client) *VolumeClient {
+	return &VolumeClient{c: c}
 }